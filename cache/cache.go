@@ -0,0 +1,21 @@
+// Package cache provides storage backends for the OpenRouter response cache.
+package cache
+
+import (
+	"time"
+)
+
+// Store stores cached responses by key. Implementations must be safe for concurrent use.
+type Store interface {
+	// Get returns the value for key, and false if it is missing or expired.
+	Get(key string) ([]byte, bool, error)
+
+	// Set stores value for key. A ttl of zero means the value doesn't expire.
+	Set(key string, value []byte, ttl time.Duration) error
+}
+
+// Stats counts the lookups of a Store.
+type Stats struct {
+	Hits   int64
+	Misses int64
+}
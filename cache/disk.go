@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// DiskStore stores every entry as a file in a directory, so it survives restarts
+// and can be shared between processes, e.g. between CI runs.
+// Keys are used as file names and must be valid file names, like the hashes used by the client.
+// Each file holds an 8 byte big endian expiry in unix nanoseconds (0 = never), followed by the value.
+type DiskStore struct {
+	dir    string
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// NewDiskStore creates a DiskStore in dir, creating the directory if needed.
+func NewDiskStore(dir string) (*DiskStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DiskStore{dir: dir}, nil
+}
+
+func (s *DiskStore) Get(key string) ([]byte, bool, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		s.misses.Add(1)
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if len(data) < 8 {
+		s.misses.Add(1)
+		return nil, false, nil
+	}
+
+	expiresAt := int64(binary.BigEndian.Uint64(data[:8]))
+	if expiresAt != 0 && time.Now().UnixNano() > expiresAt {
+		os.Remove(s.path(key))
+		s.misses.Add(1)
+		return nil, false, nil
+	}
+
+	s.hits.Add(1)
+	return data[8:], true, nil
+}
+
+func (s *DiskStore) Set(key string, value []byte, ttl time.Duration) error {
+	var expiresAt int64
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl).UnixNano()
+	}
+	data := make([]byte, 8, 8+len(value))
+	binary.BigEndian.PutUint64(data, uint64(expiresAt))
+	data = append(data, value...)
+
+	// Write to a temporary file first so readers never see a partial entry
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(key))
+}
+
+func (s *DiskStore) Stats() Stats {
+	return Stats{Hits: s.hits.Load(), Misses: s.misses.Load()}
+}
+
+func (s *DiskStore) path(key string) string {
+	return filepath.Join(s.dir, key)
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiskStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+	s, err := NewDiskStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("key", []byte("value"), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new store on the same directory, e.g. after a restart
+	reopened, err := NewDiskStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, ok, err := reopened.Get("key"); err != nil || !ok || string(value) != "value" {
+		t.Errorf("Get = %q, %v, %v, want the stored value", value, ok, err)
+	}
+	if _, ok, err := reopened.Get("missing"); err != nil || ok {
+		t.Errorf("Get of a missing key = %v, %v, want a miss", ok, err)
+	}
+	if stats := reopened.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("stats = %+v, want 1 hit and 1 miss", stats)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("directory has %d files, want no temporary files left", len(entries))
+	}
+}
+
+func TestDiskStoreTTL(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewDiskStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Set("short", []byte("1"), 10*time.Millisecond)
+	s.Set("long", []byte("2"), time.Hour)
+
+	time.Sleep(20 * time.Millisecond)
+	if _, ok, err := s.Get("short"); err != nil || ok {
+		t.Errorf("Get = %v, %v, want the expired entry missed", ok, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "short")); !os.IsNotExist(err) {
+		t.Errorf("expired entry not removed: %v", err)
+	}
+	if _, ok, _ := s.Get("long"); !ok {
+		t.Error("entry expired before its ttl")
+	}
+}
+
+func TestDiskStoreCorruptFile(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewDiskStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "key"), []byte("bad"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok, err := s.Get("key"); err != nil || ok {
+		t.Errorf("Get = %v, %v, want a truncated entry missed", ok, err)
+	}
+	if err := s.Set("key", []byte("value"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if value, ok, _ := s.Get("key"); !ok || string(value) != "value" {
+		t.Errorf("Get = %q, %v, want the corrupt entry replaced", value, ok)
+	}
+}
@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreEviction(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		// Keys set, or read when prefixed with "get "
+		ops      []string
+		wantKept []string
+		wantGone []string
+	}{
+		{
+			name:     "oldest evicted",
+			capacity: 2,
+			ops:      []string{"a", "b", "c"},
+			wantKept: []string{"b", "c"},
+			wantGone: []string{"a"},
+		},
+		{
+			name:     "reads refresh",
+			capacity: 2,
+			ops:      []string{"a", "b", "get a", "c"},
+			wantKept: []string{"a", "c"},
+			wantGone: []string{"b"},
+		},
+		{
+			name:     "updates refresh",
+			capacity: 2,
+			ops:      []string{"a", "b", "a", "c"},
+			wantKept: []string{"a", "c"},
+			wantGone: []string{"b"},
+		},
+		{
+			name:     "capacity of at least 1",
+			capacity: 0,
+			ops:      []string{"a", "b"},
+			wantKept: []string{"b"},
+			wantGone: []string{"a"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := NewMemoryStore(test.capacity)
+			for _, op := range test.ops {
+				if key, ok := strings.CutPrefix(op, "get "); ok {
+					s.Get(key)
+					continue
+				}
+				s.Set(op, []byte(op), 0)
+			}
+
+			if s.Len() != len(test.wantKept) {
+				t.Errorf("len = %d, want %d", s.Len(), len(test.wantKept))
+			}
+			for _, key := range test.wantKept {
+				if value, ok, _ := s.Get(key); !ok || string(value) != key {
+					t.Errorf("%s = %q, %v, want it kept", key, value, ok)
+				}
+			}
+			for _, key := range test.wantGone {
+				if _, ok, _ := s.Get(key); ok {
+					t.Errorf("%s kept, want it evicted", key)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStoreTTL(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	s := NewMemoryStore(10)
+	s.now = func() time.Time { return now }
+
+	s.Set("short", []byte("1"), time.Minute)
+	s.Set("forever", []byte("2"), 0)
+
+	now = now.Add(time.Minute)
+	if _, ok, _ := s.Get("short"); !ok {
+		t.Error("entry expired at its ttl, want it kept until after")
+	}
+	now = now.Add(time.Nanosecond)
+	if _, ok, _ := s.Get("short"); ok {
+		t.Error("entry kept after its ttl")
+	}
+	if s.Len() != 1 {
+		t.Errorf("len = %d, want the expired entry removed", s.Len())
+	}
+	now = now.Add(24 * time.Hour)
+	if _, ok, _ := s.Get("forever"); !ok {
+		t.Error("entry without ttl expired")
+	}
+
+	if stats := s.Stats(); stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("stats = %+v, want 2 hits and 1 miss", stats)
+	}
+}
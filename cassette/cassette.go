@@ -0,0 +1,324 @@
+// Package cassette provides an http.RoundTripper that records real request/response pairs
+// to a fixture file and replays them offline, for deterministic tests of code built on
+// the OpenRouter and Groq clients. Pass Recorder.Client() as the HTTPClient of a client config.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json/v2"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type ModeEnum string
+
+const (
+	// Replay recorded interactions, requests without a match fail
+	ModeReplay ModeEnum = "replay"
+
+	// Send every request and record it, replacing the cassette
+	ModeRecord ModeEnum = "record"
+
+	// Replay recorded interactions and record requests without a match
+	ModeReplayOrRecord ModeEnum = "replay_or_record"
+)
+
+// ErrNoMatch is matched by every *NoMatchError.
+var ErrNoMatch = errors.New("no matching interaction in cassette")
+
+// NoMatchError is returned in replay mode when no recorded interaction matches a request.
+type NoMatchError struct {
+	Method string
+	URL    string
+	Path   string
+}
+
+func (e *NoMatchError) Error() string {
+	return fmt.Sprintf("no interaction for %s %s in cassette %s", e.Method, e.URL, e.Path)
+}
+
+func (e *NoMatchError) Is(target error) bool {
+	return target == ErrNoMatch
+}
+
+// Interaction is a recorded request/response pair.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+
+	// Normalised body, see NormalizeBody
+	Body string `json:"body"`
+}
+
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+}
+
+type cassetteFile struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Config configures a Recorder.
+type Config struct {
+	// Cassette file, created in record modes
+	Path string
+
+	// Defaults to ModeReplay
+	Mode ModeEnum
+
+	// Decides which recorded interaction answers a request, defaults to
+	// NewMatcher(MatchOptions{Method: true, URL: true, Body: true})
+	Matcher Matcher
+
+	// Headers whose values are replaced by "REDACTED" before recording,
+	// in addition to Authorization, Cookie, Set-Cookie and X-Api-Key
+	RedactHeaders []string
+
+	// Used to send requests in record modes, defaults to http.DefaultTransport
+	Transport http.RoundTripper
+}
+
+// Recorder is an http.RoundTripper that records and replays interactions.
+// It is safe for concurrent use.
+type Recorder struct {
+	config Config
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+var defaultRedactHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// New creates a Recorder, loading the cassette file unless the mode is ModeRecord.
+func New(config Config) (*Recorder, error) {
+	if config.Mode == "" {
+		config.Mode = ModeReplay
+	}
+	if config.Matcher == nil {
+		config.Matcher = NewMatcher(MatchOptions{Method: true, URL: true, Body: true})
+	}
+	if config.Transport == nil {
+		config.Transport = http.DefaultTransport
+	}
+
+	r := &Recorder{config: config}
+	if config.Mode == ModeRecord {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(config.Path)
+	if errors.Is(err, os.ErrNotExist) && config.Mode == ModeReplayOrRecord {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file cassetteFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to read cassette %s: %w", config.Path, err)
+	}
+	r.interactions = file.Interactions
+	r.used = make([]bool, len(file.Interactions))
+	return r, nil
+}
+
+// Client returns an *http.Client using the Recorder as transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Interactions returns a copy of the recorded interactions.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.interactions)
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	recorded := RecordedRequest{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Header: r.redact(req.Header),
+		Body:   NormalizeBody(req.Header.Get("Content-Type"), body),
+	}
+
+	if r.config.Mode != ModeRecord {
+		if interaction, ok := r.match(req, recorded); ok {
+			return replay(req, interaction.Response), nil
+		}
+		if r.config.Mode == ModeReplay {
+			return nil, &NoMatchError{Method: req.Method, URL: recorded.URL, Path: r.config.Path}
+		}
+	}
+
+	// Send the request for real, restoring the body we consumed
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	resp, err := r.config.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pass the body through while recording, so streaming responses still stream
+	resp.Body = &recordingBody{
+		ReadCloser: resp.Body,
+		done: func(data []byte) {
+			r.record(Interaction{
+				Request: recorded,
+				Response: RecordedResponse{
+					StatusCode: resp.StatusCode,
+					Header:     r.redact(resp.Header),
+					Body:       string(data),
+				},
+			})
+		},
+	}
+	return resp, nil
+}
+
+// match returns the first unused matching interaction, or the last used one
+// if all matching interactions were replayed already.
+func (r *Recorder) match(req *http.Request, recorded RecordedRequest) (Interaction, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	last := -1
+	for i, interaction := range r.interactions {
+		if !r.config.Matcher(recorded, interaction.Request) {
+			continue
+		}
+		if !r.used[i] {
+			r.used[i] = true
+			return interaction, true
+		}
+		last = i
+	}
+	if last >= 0 {
+		return r.interactions[last], true
+	}
+	return Interaction{}, false
+}
+
+// record adds interaction and saves the cassette, so nothing is lost if a test crashes.
+func (r *Recorder) record(interaction Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.interactions = append(r.interactions, interaction)
+	r.used = append(r.used, true)
+	r.save()
+}
+
+// Save writes the cassette file. Recorded interactions are saved automatically,
+// Save reports whether that works.
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.save()
+}
+
+func (r *Recorder) save() error {
+	data, err := json.Marshal(cassetteFile{Interactions: r.interactions}, json.Deterministic(true))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.config.Path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.config.Path, data, 0o644)
+}
+
+func (r *Recorder) redact(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, name := range append(defaultRedactHeaders, r.config.RedactHeaders...) {
+		if redacted.Get(name) != "" {
+			redacted.Set(name, "REDACTED")
+		}
+	}
+	return redacted
+}
+
+// replay builds a response from a recording. Event streams are returned one
+// event per read, so streaming consumers see the events arrive separately.
+func replay(req *http.Request, recorded RecordedResponse) *http.Response {
+	var body io.Reader = strings.NewReader(recorded.Body)
+	if strings.HasPrefix(recorded.Header.Get("Content-Type"), "text/event-stream") {
+		body = &eventReader{events: strings.SplitAfter(recorded.Body, "\n\n")}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Header.Clone(),
+		Body:          io.NopCloser(body),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}
+}
+
+// recordingBody calls done with everything read once the body hits EOF or is closed.
+type recordingBody struct {
+	io.ReadCloser
+	buf  bytes.Buffer
+	once sync.Once
+	done func([]byte)
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	if err == io.EOF {
+		b.once.Do(func() { b.done(b.buf.Bytes()) })
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	b.once.Do(func() { b.done(b.buf.Bytes()) })
+	return b.ReadCloser.Close()
+}
+
+type eventReader struct {
+	events  []string
+	current string
+}
+
+func (r *eventReader) Read(p []byte) (int, error) {
+	for r.current == "" {
+		if len(r.events) == 0 {
+			return 0, io.EOF
+		}
+		r.current, r.events = r.events[0], r.events[1:]
+	}
+	n := copy(p, r.current)
+	r.current = r.current[n:]
+	return n, nil
+}
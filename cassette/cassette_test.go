@@ -0,0 +1,181 @@
+package cassette
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("network is off")
+}
+
+func post(t *testing.T, client *http.Client, url string, body string) (string, error) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	return string(data), err
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"echo":` + string(body) + `}`))
+	}))
+	path := filepath.Join(t.TempDir(), "fixtures", "chat.json")
+	url := server.URL + "/chat"
+
+	recorder, err := New(Config{Path: path, Mode: ModeRecord})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := post(t, recorder.Client(), url, `{"model": "a", "n": 1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"echo":{"model": "a", "n": 1}}`; recorded != want {
+		t.Errorf("recorded response = %q, want %q", recorded, want)
+	}
+	interactions := recorder.Interactions()
+	if len(interactions) != 1 {
+		t.Fatalf("recorded %d interactions, want 1", len(interactions))
+	}
+	if got := interactions[0].Request.Header.Get("Authorization"); got != "REDACTED" {
+		t.Errorf("Authorization recorded as %q, want it redacted", got)
+	}
+	server.Close()
+
+	// Replay with the server gone and a transport that cannot send anything
+	replayer, err := New(Config{Path: path, Transport: failingTransport{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := replayer.Client()
+
+	// Key order and whitespace do not matter, the body is normalised
+	replayed, err := post(t, client, url, `{"n":1,"model":"a"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed != recorded {
+		t.Errorf("replayed response = %q, want %q", replayed, recorded)
+	}
+
+	// A used interaction answers repeats of the same request
+	if _, err := post(t, client, url, `{"model":"a","n":1}`); err != nil {
+		t.Errorf("repeated request failed: %v", err)
+	}
+
+	_, err = post(t, client, url, `{"model":"b","n":1}`)
+	var noMatch *NoMatchError
+	if !errors.Is(err, ErrNoMatch) || !errors.As(err, &noMatch) {
+		t.Fatalf("unmatched request error = %v, want a NoMatchError", err)
+	}
+	if noMatch.Method != http.MethodPost || noMatch.URL != url || noMatch.Path != path {
+		t.Errorf("NoMatchError = %+v", noMatch)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("server got %d requests, want only the recorded one", requests.Load())
+	}
+}
+
+func TestReplayOrRecord(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(r.URL.Query().Get("q")))
+	}))
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	for range 2 {
+		recorder, err := New(Config{Path: path, Mode: ModeReplayOrRecord})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := recorder.Client().Get(server.URL + "?q=hello")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "hello" {
+			t.Errorf("body = %q, want hello", body)
+		}
+	}
+	if requests.Load() != 1 {
+		t.Errorf("server got %d requests, want the second run replayed", requests.Load())
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	recorded := RecordedRequest{Method: "POST", URL: "https://api.test/v1?a=1&key=x", Body: `{"a":1}`}
+	tests := []struct {
+		name     string
+		options  MatchOptions
+		incoming RecordedRequest
+		want     bool
+	}{
+		{"same request", MatchOptions{Method: true, URL: true, Body: true}, recorded, true},
+		{"other method", MatchOptions{Method: true}, RecordedRequest{Method: "GET"}, false},
+		{"method not compared", MatchOptions{URL: true}, RecordedRequest{Method: "GET", URL: recorded.URL}, true},
+		{"query order", MatchOptions{URL: true}, RecordedRequest{URL: "https://api.test/v1?key=x&a=1"}, true},
+		{"other query", MatchOptions{URL: true}, RecordedRequest{URL: "https://api.test/v1?a=1&key=y"}, false},
+		{"ignored query", MatchOptions{URL: true, IgnoreQuery: []string{"key"}}, RecordedRequest{URL: "https://api.test/v1?a=1&key=y"}, true},
+		{"other body", MatchOptions{Body: true}, RecordedRequest{Body: `{"a":2}`}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewMatcher(tt.options)(tt.incoming, recorded); got != tt.want {
+				t.Errorf("match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{"json", "application/json; charset=utf-8", `{ "b": [1, 2], "a": "x" }`, `{"a":"x","b":[1,2]}`},
+		{"invalid json", "application/json", `{"a":`, `{"a":`},
+		{
+			"multipart",
+			"multipart/form-data; boundary=XYZ",
+			"--XYZ\r\nContent-Disposition: form-data; name=\"model\"\r\n\r\nwhisper\r\n" +
+				"--XYZ\r\nContent-Disposition: form-data; name=\"file\"; filename=\"a.wav\"\r\n\r\nabc\r\n--XYZ--\r\n",
+			"file=file:ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad\nmodel=whisper",
+		},
+		{"text", "text/plain", "as is", "as is"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeBody(tt.contentType, []byte(tt.body)); got != tt.want {
+				t.Errorf("NormalizeBody = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
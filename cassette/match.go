@@ -0,0 +1,112 @@
+package cassette
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json/v2"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Matcher reports whether a recorded request answers an incoming request.
+type Matcher func(incoming RecordedRequest, recorded RecordedRequest) bool
+
+// MatchOptions selects the parts of a request that must match.
+type MatchOptions struct {
+	Method bool
+	URL    bool
+	Body   bool
+
+	// Query parameters ignored when matching the URL
+	IgnoreQuery []string
+}
+
+// NewMatcher returns a Matcher comparing the parts selected by options.
+// Bodies are compared after normalisation, see NormalizeBody.
+func NewMatcher(options MatchOptions) Matcher {
+	return func(incoming RecordedRequest, recorded RecordedRequest) bool {
+		if options.Method && incoming.Method != recorded.Method {
+			return false
+		}
+		if options.URL && normalizeURL(incoming.URL, options.IgnoreQuery) != normalizeURL(recorded.URL, options.IgnoreQuery) {
+			return false
+		}
+		if options.Body && incoming.Body != recorded.Body {
+			return false
+		}
+		return true
+	}
+}
+
+// NormalizeBody returns a canonical form of a request body.
+// JSON bodies are re-encoded with sorted keys and no whitespace. Multipart forms
+// are listed as sorted "name=value" lines without their random boundary, with
+// files replaced by the SHA-256 of their content. Other bodies are returned as is.
+func NormalizeBody(contentType string, body []byte) string {
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var value any
+		if err := json.Unmarshal(body, &value); err != nil {
+			return string(body)
+		}
+		canonical, err := json.Marshal(value, json.Deterministic(true))
+		if err != nil {
+			return string(body)
+		}
+		return string(canonical)
+	case strings.HasPrefix(mediaType, "multipart/"):
+		normalized, err := normalizeMultipart(body, params["boundary"])
+		if err != nil {
+			return string(body)
+		}
+		return normalized
+	default:
+		return string(body)
+	}
+}
+
+func normalizeMultipart(body []byte, boundary string) (string, error) {
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	var fields []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return "", err
+		}
+		if part.FileName() != "" {
+			sum := sha256.Sum256(data)
+			fields = append(fields, fmt.Sprintf("%s=file:%s", part.FormName(), hex.EncodeToString(sum[:])))
+		} else {
+			fields = append(fields, fmt.Sprintf("%s=%s", part.FormName(), data))
+		}
+	}
+	slices.Sort(fields)
+	return strings.Join(fields, "\n"), nil
+}
+
+func normalizeURL(rawURL string, ignoreQuery []string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := u.Query()
+	for _, key := range ignoreQuery {
+		query.Del(key)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
@@ -0,0 +1,254 @@
+// Package circuitbreaker provides a keyed circuit breaker used by the OpenRouter client
+// to stop sending requests to degraded models and providers.
+package circuitbreaker
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrOpen is matched by every *OpenError, use errors.Is(err, circuitbreaker.ErrOpen).
+var ErrOpen = errors.New("circuit breaker open")
+
+// OpenError is returned when a request is rejected because the circuit of its key is open.
+type OpenError struct {
+	Key string
+
+	// Time until the circuit half-opens
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("circuit breaker for %q is open, retry after %s", e.Key, e.RetryAfter)
+}
+
+func (e *OpenError) Is(target error) bool {
+	return target == ErrOpen
+}
+
+type StateEnum string
+
+const (
+	// Requests are allowed
+	StateClosed StateEnum = "closed"
+
+	// Requests are rejected until the open duration has passed
+	StateOpen StateEnum = "open"
+
+	// A limited number of probe requests are allowed to test recovery
+	StateHalfOpen StateEnum = "half_open"
+)
+
+// ModelKey returns the key used for a model.
+func ModelKey(model string) string {
+	return "model:" + model
+}
+
+// ProviderKey returns the key used for an upstream provider, e.g. "Together".
+func ProviderKey(provider string) string {
+	return "provider:" + provider
+}
+
+// Config defines when circuits open and close. Zero values use the defaults.
+type Config struct {
+	// Consecutive failures after which a circuit opens, defaults to 5
+	FailureThreshold int
+
+	// How long a circuit stays open before half-opening, defaults to 30 seconds
+	OpenDuration time.Duration
+
+	// Probe requests allowed while half-open. The circuit closes once all of them
+	// succeed and opens again on the first failure. Defaults to 1.
+	HalfOpenProbes int
+
+	// Called whenever a circuit changes state, with the Breaker locked.
+	// It must not call the Breaker.
+	OnStateChange func(key string, from StateEnum, to StateEnum)
+}
+
+// Breaker tracks a circuit per key. It is safe for concurrent use.
+type Breaker struct {
+	config Config
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+	now      func() time.Time
+}
+
+type circuit struct {
+	state     StateEnum
+	failures  int
+	openedAt  time.Time
+	probes    int
+	successes int
+}
+
+func New(config Config) *Breaker {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 5
+	}
+	if config.OpenDuration <= 0 {
+		config.OpenDuration = 30 * time.Second
+	}
+	if config.HalfOpenProbes <= 0 {
+		config.HalfOpenProbes = 1
+	}
+	return &Breaker{
+		config:   config,
+		circuits: map[string]*circuit{},
+		now:      time.Now,
+	}
+}
+
+// Allow reports whether a request for key may be sent, returning an *OpenError if not.
+// Every allowed request must be followed by a call to Success, Failure or Release.
+func (b *Breaker) Allow(key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(key)
+	b.halfOpenIfDue(key, c)
+
+	switch c.state {
+	case StateOpen:
+		return &OpenError{Key: key, RetryAfter: c.openedAt.Add(b.config.OpenDuration).Sub(b.now())}
+	case StateHalfOpen:
+		if c.probes >= b.config.HalfOpenProbes {
+			return &OpenError{Key: key}
+		}
+		c.probes++
+	}
+	return nil
+}
+
+// Success records a successful request for key.
+func (b *Breaker) Success(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(key)
+	switch c.state {
+	case StateHalfOpen:
+		c.successes++
+		if c.successes >= b.config.HalfOpenProbes {
+			b.transition(key, c, StateClosed)
+		}
+	default:
+		c.failures = 0
+	}
+}
+
+// Failure records a failed request for key.
+func (b *Breaker) Failure(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(key)
+	switch c.state {
+	case StateHalfOpen:
+		b.transition(key, c, StateOpen)
+	case StateClosed:
+		c.failures++
+		if c.failures >= b.config.FailureThreshold {
+			b.transition(key, c, StateOpen)
+		}
+	}
+}
+
+// Release gives back a probe allowed by Allow without recording an outcome,
+// e.g. when the request was cancelled by the caller.
+func (b *Breaker) Release(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(key)
+	if c.state == StateHalfOpen && c.probes > 0 {
+		c.probes--
+	}
+}
+
+// State returns the current state of the circuit for key.
+func (b *Breaker) State(key string) StateEnum {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(key)
+	b.halfOpenIfDue(key, c)
+	return c.state
+}
+
+// OpenKeys returns the keys with the given prefix whose circuit is open,
+// with the prefix trimmed. E.g. OpenKeys(ProviderKey("")) returns the open providers.
+func (b *Breaker) OpenKeys(prefix string) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var keys []string
+	for key, c := range b.circuits {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		b.halfOpenIfDue(key, c)
+		if c.state == StateOpen {
+			keys = append(keys, strings.TrimPrefix(key, prefix))
+		}
+	}
+	return keys
+}
+
+// AllowPrefix is Allow for every key with the given prefix, for keys only known after a request
+// was sent, e.g. the provider OpenRouter routed it to. It returns the keys whose circuit rejects
+// requests and the half-open keys allowed a probe request, with the prefix trimmed.
+// Every probe must be followed by a call to Success, Failure or Release.
+func (b *Breaker) AllowPrefix(prefix string) ([]string, []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var rejected, probes []string
+	for key, c := range b.circuits {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		b.halfOpenIfDue(key, c)
+		switch {
+		case c.state == StateOpen, c.state == StateHalfOpen && c.probes >= b.config.HalfOpenProbes:
+			rejected = append(rejected, strings.TrimPrefix(key, prefix))
+		case c.state == StateHalfOpen:
+			c.probes++
+			probes = append(probes, strings.TrimPrefix(key, prefix))
+		}
+	}
+	return rejected, probes
+}
+
+func (b *Breaker) circuit(key string) *circuit {
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{state: StateClosed}
+		b.circuits[key] = c
+	}
+	return c
+}
+
+func (b *Breaker) halfOpenIfDue(key string, c *circuit) {
+	if c.state == StateOpen && !b.now().Before(c.openedAt.Add(b.config.OpenDuration)) {
+		b.transition(key, c, StateHalfOpen)
+	}
+}
+
+func (b *Breaker) transition(key string, c *circuit, to StateEnum) {
+	from := c.state
+	c.state = to
+	c.failures = 0
+	c.probes = 0
+	c.successes = 0
+	if to == StateOpen {
+		c.openedAt = b.now()
+	}
+	if b.config.OnStateChange != nil && from != to {
+		b.config.OnStateChange(key, from, to)
+	}
+}
@@ -0,0 +1,143 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func newTestBreaker(config Config) (*Breaker, *time.Time, *[]string) {
+	now := time.Unix(1_700_000_000, 0)
+	var changes []string
+	config.OnStateChange = func(key string, from StateEnum, to StateEnum) {
+		changes = append(changes, key+" "+string(from)+" -> "+string(to))
+	}
+	b := New(config)
+	b.now = func() time.Time { return now }
+	return b, &now, &changes
+}
+
+func TestStateMachine(t *testing.T) {
+	b, now, changes := newTestBreaker(Config{FailureThreshold: 3, OpenDuration: 10 * time.Second, HalfOpenProbes: 2})
+	key := ModelKey("m")
+
+	// Successes reset the count of consecutive failures
+	b.Failure(key)
+	b.Failure(key)
+	b.Success(key)
+	b.Failure(key)
+	b.Failure(key)
+	if state := b.State(key); state != StateClosed {
+		t.Fatalf("state = %s after non-consecutive failures, want closed", state)
+	}
+
+	b.Failure(key)
+	if state := b.State(key); state != StateOpen {
+		t.Fatalf("state = %s after 3 failures, want open", state)
+	}
+	var openErr *OpenError
+	*now = now.Add(4 * time.Second)
+	if err := b.Allow(key); !errors.As(err, &openErr) || !errors.Is(err, ErrOpen) || openErr.RetryAfter != 6*time.Second {
+		t.Fatalf("Allow = %v, want an *OpenError retrying after 6s", err)
+	}
+
+	*now = now.Add(6 * time.Second)
+	if state := b.State(key); state != StateHalfOpen {
+		t.Fatalf("state = %s after the open duration, want half open", state)
+	}
+	for i := range 2 {
+		if err := b.Allow(key); err != nil {
+			t.Fatalf("probe %d: %v", i, err)
+		}
+	}
+	if err := b.Allow(key); !errors.Is(err, ErrOpen) {
+		t.Fatalf("Allow = %v beyond the probe limit, want ErrOpen", err)
+	}
+
+	// A released probe can be taken again
+	b.Release(key)
+	if err := b.Allow(key); err != nil {
+		t.Fatalf("Allow after Release = %v", err)
+	}
+
+	b.Success(key)
+	if state := b.State(key); state != StateHalfOpen {
+		t.Fatalf("state = %s after 1 of 2 probes succeeded, want half open", state)
+	}
+	b.Success(key)
+	if state := b.State(key); state != StateClosed {
+		t.Fatalf("state = %s after all probes succeeded, want closed", state)
+	}
+
+	want := []string{
+		"model:m closed -> open",
+		"model:m open -> half_open",
+		"model:m half_open -> closed",
+	}
+	if !slices.Equal(*changes, want) {
+		t.Errorf("state changes = %v, want %v", *changes, want)
+	}
+}
+
+func TestHalfOpenFailureReopens(t *testing.T) {
+	b, now, _ := newTestBreaker(Config{FailureThreshold: 1, OpenDuration: time.Second})
+	key := ModelKey("m")
+
+	b.Failure(key)
+	*now = now.Add(time.Second)
+	if err := b.Allow(key); err != nil {
+		t.Fatal(err)
+	}
+	b.Failure(key)
+	if state := b.State(key); state != StateOpen {
+		t.Fatalf("state = %s after a failed probe, want open", state)
+	}
+	var openErr *OpenError
+	if err := b.Allow(key); !errors.As(err, &openErr) || openErr.RetryAfter != time.Second {
+		t.Errorf("Allow = %v, want the open duration to start again", err)
+	}
+}
+
+func TestOpenKeysAndAllowPrefix(t *testing.T) {
+	b, now, _ := newTestBreaker(Config{FailureThreshold: 1, OpenDuration: 10 * time.Second})
+	b.Failure(ProviderKey("A"))
+	b.Failure(ProviderKey("B"))
+	b.Failure(ModelKey("m"))
+	b.Success(ProviderKey("C"))
+
+	open := b.OpenKeys(ProviderKey(""))
+	slices.Sort(open)
+	if !slices.Equal(open, []string{"A", "B"}) {
+		t.Errorf("OpenKeys = %v, want the open providers without the model", open)
+	}
+	rejected, probes := b.AllowPrefix(ProviderKey(""))
+	slices.Sort(rejected)
+	if !slices.Equal(rejected, []string{"A", "B"}) || len(probes) != 0 {
+		t.Errorf("AllowPrefix = %v, %v, want A and B rejected", rejected, probes)
+	}
+
+	*now = now.Add(5 * time.Second)
+	b.Failure(ProviderKey("C"))
+	*now = now.Add(5 * time.Second)
+	if open := b.OpenKeys(ProviderKey("")); !slices.Equal(open, []string{"C"}) {
+		t.Errorf("OpenKeys = %v, want only C, A and B are half open", open)
+	}
+
+	rejected, probes = b.AllowPrefix(ProviderKey(""))
+	slices.Sort(probes)
+	if !slices.Equal(rejected, []string{"C"}) || !slices.Equal(probes, []string{"A", "B"}) {
+		t.Fatalf("AllowPrefix = %v, %v, want C rejected and probes for A and B", rejected, probes)
+	}
+	// The probes are taken until they are resolved or released
+	rejected, probes = b.AllowPrefix(ProviderKey(""))
+	if len(rejected) != 3 || len(probes) != 0 {
+		t.Errorf("AllowPrefix = %v, %v, want all providers rejected while probing", rejected, probes)
+	}
+	b.Success(ProviderKey("A"))
+	b.Release(ProviderKey("B"))
+	rejected, probes = b.AllowPrefix(ProviderKey(""))
+	if !slices.Equal(rejected, []string{"C"}) || !slices.Equal(probes, []string{"B"}) {
+		t.Errorf("AllowPrefix = %v, %v, want C rejected and a probe for B", rejected, probes)
+	}
+}
@@ -0,0 +1,413 @@
+package clients
+
+import (
+	"bufio"
+	"context"
+	"encoding/json/v2"
+	"errors"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Floris22/go-llm/v2/circuitbreaker"
+	h "github.com/Floris22/go-llm/v2/internal/helpers"
+	t "github.com/Floris22/go-llm/v2/llmtypes"
+	"github.com/Floris22/go-llm/v2/ratelimit"
+)
+
+// BatchRequest is a single request of a batch.
+// Requests with a Schema use GenerateStructured, requests with Tools use GenerateTools
+// and all others use GenerateText.
+type BatchRequest struct {
+	// Identifies the request in the checkpoint file, defaults to its index in the batch
+	ID string
+
+	Messages     []t.MessageForLLM
+	MessageParts []t.PartMessageForLLM
+	Schema       *t.StructuredOutputSchema
+	Tools        []t.ToolSchema
+	Model        string
+	Temperature  *float64
+	MaxTokens    *int
+	TimeOut      *int
+	Reasoning    *t.ReasoningConfig
+	Provider     *t.ProviderConfig
+	Plugins      []t.Plugin
+}
+
+// BatchResult is the outcome of a single BatchRequest.
+type BatchResult struct {
+	// Index of the request in the batch, or its position on the request channel
+	Index int
+	ID    string
+
+	Response t.OpenRouterResponse
+	Err      error
+
+	// Number of times the request was sent, 0 if it was resumed or never sent
+	Attempts int
+
+	// The result was loaded from the checkpoint file
+	Resumed bool
+
+	// Set if the result couldn't be written to the checkpoint file
+	CheckpointErr error
+}
+
+// BatchConfig configures a batch run. Zero values use the defaults.
+type BatchConfig struct {
+	// Maximum requests in flight, defaults to 4
+	Concurrency int
+
+	// Maximum times each request is sent, defaults to 3
+	MaxAttempts int
+
+	// Emit results in request order instead of as they complete
+	Ordered bool
+
+	// Optional JSONL file that successful results are appended to.
+	// Requests whose ID is already in the file are not sent again, their stored result is returned.
+	CheckpointPath string
+
+	// Optional limiter waited on before each attempt, in addition to the client's own limiter
+	RateLimiter ratelimit.Limiter
+}
+
+// RunBatch sends all requests with bounded concurrency and returns their results in request order.
+// Per-request errors are returned in the results, the returned error is only set
+// if the checkpoint file can't be read.
+func RunBatch(
+	ctx context.Context,
+	client OpenRouterClient,
+	requests []BatchRequest,
+	config BatchConfig,
+) ([]BatchResult, error) {
+	requestChan := make(chan BatchRequest)
+	go func() {
+		defer close(requestChan)
+		for _, req := range requests {
+			select {
+			case requestChan <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	resultChan, err := StreamBatch(ctx, client, requestChan, config)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult, len(requests))
+	received := make([]bool, len(requests))
+	for result := range resultChan {
+		results[result.Index] = result
+		received[result.Index] = true
+	}
+
+	// Requests that were never dispatched because ctx was cancelled
+	for index, ok := range received {
+		if !ok {
+			results[index] = BatchResult{Index: index, ID: batchID(requests[index].ID, index), Err: context.Cause(ctx)}
+		}
+	}
+	return results, nil
+}
+
+// StreamBatch sends the requests received on requests with bounded concurrency and
+// emits a result for each of them. The result channel is closed once requests is closed
+// and all results are emitted, or ctx is cancelled and all running requests finished.
+func StreamBatch(
+	ctx context.Context,
+	client OpenRouterClient,
+	requests <-chan BatchRequest,
+	config BatchConfig,
+) (<-chan BatchResult, error) {
+	if config.Concurrency <= 0 {
+		config.Concurrency = 4
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 3
+	}
+
+	var checkpoint *batchCheckpoint
+	if config.CheckpointPath != "" {
+		var err error
+		checkpoint, err = openBatchCheckpoint(config.CheckpointPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	type job struct {
+		index int
+		req   BatchRequest
+	}
+	jobs := make(chan job)
+	results := make(chan BatchResult)
+	var wg sync.WaitGroup
+
+	// Dispatch requests, resumed requests skip the workers
+	wg.Go(func() {
+		defer close(jobs)
+		index := 0
+		for {
+			var req BatchRequest
+			var ok bool
+			select {
+			case req, ok = <-requests:
+			case <-ctx.Done():
+				return
+			}
+			if !ok {
+				return
+			}
+
+			id := batchID(req.ID, index)
+			if response, done := checkpoint.lookup(id); done {
+				results <- BatchResult{Index: index, ID: id, Response: response, Resumed: true}
+			} else {
+				select {
+				case jobs <- job{index: index, req: req}:
+				case <-ctx.Done():
+					return
+				}
+			}
+			index++
+		}
+	})
+
+	for range config.Concurrency {
+		wg.Go(func() {
+			for j := range jobs {
+				result := runBatchRequest(ctx, client, j.req, config)
+				result.Index = j.index
+				result.ID = batchID(j.req.ID, j.index)
+				if result.Err == nil {
+					result.CheckpointErr = checkpoint.write(result.ID, result.Response)
+				}
+				results <- result
+			}
+		})
+	}
+
+	go func() {
+		wg.Wait()
+		checkpoint.close()
+		close(results)
+	}()
+
+	if !config.Ordered {
+		return results, nil
+	}
+
+	ordered := make(chan BatchResult)
+	go func() {
+		defer close(ordered)
+		pending := map[int]BatchResult{}
+		next := 0
+		for result := range results {
+			pending[result.Index] = result
+			for {
+				result, ok := pending[next]
+				if !ok {
+					break
+				}
+				ordered <- result
+				delete(pending, next)
+				next++
+			}
+		}
+		// Results after a gap left by cancellation
+		for len(pending) > 0 {
+			if result, ok := pending[next]; ok {
+				ordered <- result
+				delete(pending, next)
+			}
+			next++
+		}
+	}()
+	return ordered, nil
+}
+
+// runBatchRequest sends req until it succeeds, fails with an error that isn't
+// worth retrying, MaxAttempts is reached or ctx is done.
+func runBatchRequest(ctx context.Context, client OpenRouterClient, req BatchRequest, config BatchConfig) BatchResult {
+	var result BatchResult
+	limitReq := batchLimitRequest(client, req)
+	for attempt := range config.MaxAttempts {
+		if err := waitBatchLimiter(ctx, config.RateLimiter, limitReq); err != nil {
+			result.Err = err
+			return result
+		}
+
+		result.Attempts++
+		result.Response, result.Err = sendBatchRequest(client, req)
+		if result.Err == nil || attempt+1 == config.MaxAttempts || !retryableBatchError(result.Err) {
+			return result
+		}
+
+		backoff := time.Duration(500*math.Pow(2, float64(attempt))) * time.Millisecond
+		var limitErr *ratelimit.LimitError
+		var openErr *circuitbreaker.OpenError
+		if errors.As(result.Err, &limitErr) {
+			backoff = max(backoff, limitErr.RetryAfter)
+		} else if errors.As(result.Err, &openErr) {
+			backoff = max(backoff, openErr.RetryAfter)
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return result
+		}
+	}
+	return result
+}
+
+// retryableBatchError reports whether a request that failed with err may succeed when sent again:
+// timeouts, rate limits, open circuits and 408, 429 and 5xx responses.
+func retryableBatchError(err error) bool {
+	var timeoutErr *t.TimeoutError
+	var limitErr *ratelimit.LimitError
+	var openErr *circuitbreaker.OpenError
+	var apiErr *t.APIError
+	switch {
+	case errors.As(err, &timeoutErr), errors.As(err, &limitErr), errors.As(err, &openErr):
+		return true
+	case errors.As(err, &apiErr):
+		return apiErr.StatusCode == 408 || apiErr.StatusCode == 429 || apiErr.StatusCode >= 500
+	}
+	return false
+}
+
+// batchLimitRequest returns the rate limiter request for req as the client's own limiter builds it.
+func batchLimitRequest(client OpenRouterClient, req BatchRequest) ratelimit.Request {
+	limitReq := ratelimit.Request{Model: req.Model}
+	if c, ok := client.(*openRouterClient); ok {
+		limitReq.APIKey = c.apiKey
+		if limitReq.Model == "" {
+			limitReq.Model = c.config.Model
+		}
+	}
+
+	var tools *[]t.ToolSchema
+	if req.Schema == nil && len(req.Tools) > 0 {
+		tools = &req.Tools
+	}
+	// Invalid requests fail when they are sent
+	if body, err := h.CreateRequestBody(req.Messages, req.MessageParts, limitReq.Model, req.Temperature, req.MaxTokens, req.Schema, tools, req.Reasoning, req.Provider, req.Plugins); err == nil {
+		limitReq.Tokens = h.EstimateTokens(body)
+	}
+	return limitReq
+}
+
+// waitBatchLimiter waits for limiter, also when it is in fail fast mode.
+func waitBatchLimiter(ctx context.Context, limiter ratelimit.Limiter, limitReq ratelimit.Request) error {
+	if limiter == nil {
+		return nil
+	}
+	for {
+		err := limiter.Wait(ctx, limitReq)
+		var limitErr *ratelimit.LimitError
+		if !errors.As(err, &limitErr) {
+			return err
+		}
+		select {
+		case <-time.After(limitErr.RetryAfter):
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	}
+}
+
+func sendBatchRequest(client OpenRouterClient, req BatchRequest) (t.OpenRouterResponse, error) {
+	switch {
+	case req.Schema != nil:
+		return client.GenerateStructured(req.Messages, req.MessageParts, *req.Schema, req.Model, req.Temperature, req.MaxTokens, req.TimeOut, req.Reasoning, req.Provider, req.Plugins)
+	case len(req.Tools) > 0:
+		return client.GenerateTools(req.Messages, req.MessageParts, req.Tools, req.Model, req.Temperature, req.MaxTokens, req.TimeOut, req.Reasoning, req.Provider, req.Plugins)
+	default:
+		return client.GenerateText(req.Messages, req.MessageParts, req.Model, req.Temperature, req.MaxTokens, req.TimeOut, req.Reasoning, req.Provider, req.Plugins)
+	}
+}
+
+func batchID(id string, index int) string {
+	if id != "" {
+		return id
+	}
+	return strconv.Itoa(index)
+}
+
+// batchCheckpoint stores successful batch results as JSON lines.
+// A nil *batchCheckpoint is valid and stores nothing.
+type batchCheckpoint struct {
+	mu        sync.Mutex
+	file      *os.File
+	completed map[string]t.OpenRouterResponse
+}
+
+type batchCheckpointLine struct {
+	ID       string               `json:"id"`
+	Response t.OpenRouterResponse `json:"response"`
+}
+
+func openBatchCheckpoint(path string) (*batchCheckpoint, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	completed := map[string]t.OpenRouterResponse{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		var line batchCheckpointLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			// A line cut off by a crash, the request is sent again
+			continue
+		}
+		completed[line.ID] = line.Response
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &batchCheckpoint{file: file, completed: completed}, nil
+}
+
+func (c *batchCheckpoint) lookup(id string) (t.OpenRouterResponse, bool) {
+	if c == nil {
+		return t.OpenRouterResponse{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	response, ok := c.completed[id]
+	return response, ok
+}
+
+func (c *batchCheckpoint) write(id string, response t.OpenRouterResponse) error {
+	if c == nil {
+		return nil
+	}
+	line, err := json.Marshal(batchCheckpointLine{ID: id, Response: response})
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.completed[id] = response
+	_, err = c.file.Write(append(line, '\n'))
+	return err
+}
+
+func (c *batchCheckpoint) close() {
+	if c != nil {
+		c.file.Close()
+	}
+}
@@ -0,0 +1,193 @@
+package clients_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Floris22/go-llm/v2/clients"
+	"github.com/Floris22/go-llm/v2/fakes"
+	"github.com/Floris22/go-llm/v2/llmtypes"
+	"github.com/Floris22/go-llm/v2/mockserver"
+	"github.com/Floris22/go-llm/v2/ratelimit"
+)
+
+func batchRequest(id string, model string) clients.BatchRequest {
+	content := "hello " + id
+	return clients.BatchRequest{
+		ID:       id,
+		Model:    model,
+		Messages: []llmtypes.MessageForLLM{{Role: llmtypes.RoleUser, Content: &content}},
+	}
+}
+
+func TestRunBatchRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		replies      []fakes.OpenRouterReply
+		wantAttempts int
+		wantErr      bool
+	}{
+		{
+			name:         "bad request is not retried",
+			replies:      []fakes.OpenRouterReply{fakes.StatusReply(400, "bad request")},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "validation error is not retried",
+			replies:      []fakes.OpenRouterReply{fakes.ErrorReply(errors.New("Must send either message or message parts"))},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "rate limit is retried",
+			replies:      []fakes.OpenRouterReply{fakes.StatusReply(429, "slow down"), fakes.TextReply("ok")},
+			wantAttempts: 2,
+		},
+		{
+			name:         "timeout is retried",
+			replies:      []fakes.OpenRouterReply{fakes.ErrorReply(&llmtypes.TimeoutError{Phase: llmtypes.TimeoutPhaseFirstToken}), fakes.TextReply("ok")},
+			wantAttempts: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fakes.NewFakeOpenRouterClient().Enqueue(test.replies...)
+			results, err := clients.RunBatch(context.Background(), client, []clients.BatchRequest{batchRequest("a", "m")}, clients.BatchConfig{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if results[0].Attempts != test.wantAttempts {
+				t.Errorf("attempts = %d, want %d", results[0].Attempts, test.wantAttempts)
+			}
+			if (results[0].Err != nil) != test.wantErr {
+				t.Errorf("err = %v, want error %v", results[0].Err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestStreamBatchOrderedAfterCancel(t *testing.T) {
+	client := fakes.NewFakeOpenRouterClient()
+	requests := make(chan clients.BatchRequest)
+	go func() {
+		defer close(requests)
+		// Later requests finish first
+		for i := range 20 {
+			model := fmt.Sprintf("m%d", i)
+			client.On(fakes.ForModel(model)).Always(fakes.OpenRouterReply{Latency: time.Duration(20-i) * 5 * time.Millisecond})
+			requests <- batchRequest(model, model)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results, err := clients.StreamBatch(ctx, client, requests, clients.BatchConfig{Concurrency: 4, Ordered: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	last := -1
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case result, ok := <-results:
+			if !ok {
+				if last < 0 {
+					t.Fatal("no results before the channel closed")
+				}
+				return
+			}
+			if result.Index <= last {
+				t.Fatalf("result %d emitted after %d", result.Index, last)
+			}
+			last = result.Index
+			if last == 2 {
+				cancel()
+			}
+		case <-timeout:
+			t.Fatal("result channel not closed after cancellation")
+		}
+	}
+}
+
+func TestRunBatchResumesFromCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.jsonl")
+	requests := []clients.BatchRequest{batchRequest("a", "m"), batchRequest("b", "m"), batchRequest("c", "m")}
+
+	first := fakes.NewFakeOpenRouterClient().SetDefault(fakes.TextReply("first"))
+	first.On(fakes.MessagesContain("hello b")).Always(fakes.StatusReply(400, "bad request"))
+	results, err := clients.RunBatch(context.Background(), first, requests, clients.BatchConfig{CheckpointPath: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[1].Err == nil {
+		t.Fatal("expected request b to fail")
+	}
+
+	second := fakes.NewFakeOpenRouterClient().SetDefault(fakes.TextReply("second"))
+	results, err = clients.RunBatch(context.Background(), second, requests, clients.BatchConfig{CheckpointPath: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		content string
+		resumed bool
+	}{{"first", true}, {"second", false}, {"first", true}}
+	for i, result := range results {
+		if result.Err != nil {
+			t.Fatalf("result %d: %v", i, result.Err)
+		}
+		if got := result.Response.Choices[0].Message.Content; got != want[i].content || result.Resumed != want[i].resumed {
+			t.Errorf("result %d = %q resumed %v, want %q resumed %v", i, got, result.Resumed, want[i].content, want[i].resumed)
+		}
+	}
+	if calls := second.Calls(); len(calls) != 1 {
+		t.Errorf("second run sent %d requests, want 1", len(calls))
+	}
+}
+
+// recordingLimiter records the requests it is asked to wait for.
+type recordingLimiter struct {
+	mu       sync.Mutex
+	requests []ratelimit.Request
+}
+
+func (l *recordingLimiter) Wait(ctx context.Context, req ratelimit.Request) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.requests = append(l.requests, req)
+	return nil
+}
+
+func (l *recordingLimiter) Observe(ratelimit.Request, ratelimit.Observation) {}
+
+func TestRunBatchLimiterRequest(t *testing.T) {
+	server := mockserver.New()
+	defer server.Close()
+	client := clients.NewOpenRouterClientWithConfig("key", clients.OpenRouterConfig{
+		Model:   "default-model",
+		BaseURL: server.OpenRouterURL(),
+	})
+
+	limiter := &recordingLimiter{}
+	_, err := clients.RunBatch(context.Background(), client, []clients.BatchRequest{batchRequest("a", "")}, clients.BatchConfig{RateLimiter: limiter})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(limiter.requests) != 1 {
+		t.Fatalf("limiter waited %d times, want 1", len(limiter.requests))
+	}
+	req := limiter.requests[0]
+	if req.APIKey != "key" || req.Model != "default-model" || req.Tokens == 0 {
+		t.Errorf("limiter request = %+v, want the API key, default model and a token estimate", req)
+	}
+}
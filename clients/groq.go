@@ -1,15 +1,64 @@
 package clients
 
 import (
+	"bytes"
+	"context"
+	"encoding/json/v2"
 	"errors"
+	"io"
+	"math"
+	"net"
+	"net/http"
 	"os"
-	"strings"
+	"slices"
+	"sync"
+	"time"
 
 	h "github.com/Floris22/go-llm/v2/internal/helpers"
 	t "github.com/Floris22/go-llm/v2/llmtypes"
+	"github.com/Floris22/go-llm/v2/ratelimit"
 )
 
 type GroqClient interface {
+	// GenerateText generates a chat completion with Groq's OpenAI compatible API.
+	// The response has the same shape as OpenRouter's, without cost and provider.
+	// When maxTokens is nil max_tokens is left out of the request and the model's own limit applies.
+	GenerateText(
+		messages []t.MessageForLLM,
+		messageParts []t.PartMessageForLLM,
+		model string,
+		temperature *float64,
+		maxTokens *int,
+		timeOut *int,
+	) (t.OpenRouterResponse, error)
+
+	// GenerateTools is GenerateText requiring a call to one of tools.
+	GenerateTools(
+		messages []t.MessageForLLM,
+		messageParts []t.PartMessageForLLM,
+		tools []t.ToolSchema,
+		model string,
+		temperature *float64,
+		maxTokens *int,
+		timeOut *int,
+	) (t.OpenRouterResponse, error)
+
+	// GenerateStructured is GenerateText with a JSON response matching schema.
+	GenerateStructured(
+		messages []t.MessageForLLM,
+		messageParts []t.PartMessageForLLM,
+		schema t.StructuredOutputSchema,
+		model string,
+		temperature *float64,
+		maxTokens *int,
+		timeOut *int,
+	) (t.OpenRouterResponse, error)
+
+	// Transcribe transcribes speech to text. language is an ISO-639-1 code, e.g. "en",
+	// when empty the language is detected and reported in the response.
+	// Audio up to 20MB in a format Groq accepts is sent unchanged. Larger audio is decoded
+	// and split into chunks, when some chunks fail the others are returned
+	// with a *llmtypes.PartialTranscriptionError.
 	Transcribe(
 		model string,
 		language string,
@@ -17,15 +66,123 @@ type GroqClient interface {
 		audioBytes *[]byte,
 		timeOut *int,
 	) (t.GroqTranscriptionResponse, error)
+
+	// TranscribeWithOptions is Transcribe with optional settings, e.g. word timestamps.
+	TranscribeWithOptions(
+		model string,
+		language string,
+		audioURL *string,
+		audioBytes *[]byte,
+		timeOut *int,
+		options *t.TranscriptionOptions,
+	) (t.GroqTranscriptionResponse, error)
+
+	// TranscribeReader is TranscribeWithOptions for audio read from audio, e.g. an upload.
+	// Large audio is decoded and split while it is read instead of being held in memory.
+	TranscribeReader(
+		model string,
+		language string,
+		audio io.Reader,
+		timeOut *int,
+		options *t.TranscriptionOptions,
+	) (t.GroqTranscriptionResponse, error)
+
+	// TranscribeFile is TranscribeReader for the audio file at path.
+	TranscribeFile(
+		model string,
+		language string,
+		path string,
+		timeOut *int,
+		options *t.TranscriptionOptions,
+	) (t.GroqTranscriptionResponse, error)
+
+	// Translate transcribes speech in any language to English text.
+	Translate(
+		model string,
+		audioURL *string,
+		audioBytes *[]byte,
+		timeOut *int,
+	) (t.GroqTranscriptionResponse, error)
+
+	// TranslateWithOptions is Translate with optional settings.
+	TranslateWithOptions(
+		model string,
+		audioURL *string,
+		audioBytes *[]byte,
+		timeOut *int,
+		options *t.TranscriptionOptions,
+	) (t.GroqTranscriptionResponse, error)
+
+	// TranslateReader is TranslateWithOptions for audio read from audio, see TranscribeReader.
+	TranslateReader(
+		model string,
+		audio io.Reader,
+		timeOut *int,
+		options *t.TranscriptionOptions,
+	) (t.GroqTranscriptionResponse, error)
+
+	// TranslateFile is TranslateReader for the audio file at path.
+	TranslateFile(
+		model string,
+		path string,
+		timeOut *int,
+		options *t.TranscriptionOptions,
+	) (t.GroqTranscriptionResponse, error)
+}
+
+// Audio files larger than this are split, below Groq's 25MB upload limit
+const maxAudioFileSize = 20 * 1024 * 1024
+
+// GroqConfig holds client-wide settings for the GroqClient.
+type GroqConfig struct {
+	// HTTP client used for all requests, defaults to http.DefaultClient.
+	// E.g. use a cassette.Recorder's client to record and replay requests in tests.
+	HTTPClient *http.Client
+
+	// API base URL, defaults to https://api.groq.com/openai/v1.
+	// E.g. use a mockserver.Server's GroqURL in integration tests.
+	BaseURL string
+
+	// Optional client-side rate limiter, consulted before every request including retries.
+	// Chat requests are charged by their estimated tokens, audio chunks by their duration
+	// and audio URLs once the response reports it.
+	RateLimiter ratelimit.Limiter
+
+	// Extra headers sent with every request.
+	// Authorization defaults to the API key.
+	Headers map[string]string
+
+	// Middleware around every logical call, including its chunks and retries.
+	// The first middleware is the outermost.
+	Middleware []Middleware
+
+	// Middleware around every HTTP request, including retries.
+	// The first middleware is the outermost.
+	AttemptMiddleware []AttemptMiddleware
+
+	// Optional structured logging of every call and request, see LogConfig
+	Logging *LogConfig
 }
 
 type groqClient struct {
 	apiKey string
+	config GroqConfig
 }
 
 func NewGroqClient(apiKey string) GroqClient {
+	return NewGroqClientWithConfig(apiKey, GroqConfig{})
+}
+
+func NewGroqClientWithConfig(apiKey string, config GroqConfig) GroqClient {
+	if config.BaseURL == "" {
+		config.BaseURL = "https://api.groq.com/openai/v1"
+	}
+	config.Middleware, config.AttemptMiddleware = withLogging(
+		config.Logging, apiKey, config.Middleware, config.AttemptMiddleware,
+	)
 	return &groqClient{
 		apiKey: apiKey,
+		config: config,
 	}
 }
 
@@ -36,51 +193,472 @@ func (c *groqClient) Transcribe(
 	audioBytes *[]byte,
 	timeOut *int,
 ) (t.GroqTranscriptionResponse, error) {
+	return c.TranscribeWithOptions(model, language, audioURL, audioBytes, timeOut, nil)
+}
+
+func (c *groqClient) TranscribeWithOptions(
+	model string,
+	language string,
+	audioURL *string,
+	audioBytes *[]byte,
+	timeOut *int,
+	options *t.TranscriptionOptions,
+) (t.GroqTranscriptionResponse, error) {
+	if err := checkAudioSource(audioURL, audioBytes); err != nil {
+		return t.GroqTranscriptionResponse{}, err
+	}
+	return c.audio(&Call{
+		Operation:  OperationTranscribe,
+		Model:      model,
+		TimeOut:    timeOut,
+		Language:   language,
+		AudioURL:   audioURL,
+		AudioBytes: audioBytes,
+		Options:    options,
+	})
+}
+
+func (c *groqClient) TranscribeReader(
+	model string,
+	language string,
+	audio io.Reader,
+	timeOut *int,
+	options *t.TranscriptionOptions,
+) (t.GroqTranscriptionResponse, error) {
+	if audio == nil {
+		return t.GroqTranscriptionResponse{}, errors.New("Audio must not be nil.")
+	}
+	return c.audio(&Call{
+		Operation: OperationTranscribe,
+		Model:     model,
+		TimeOut:   timeOut,
+		Language:  language,
+		Audio:     audio,
+		Options:   options,
+	})
+}
+
+func (c *groqClient) TranscribeFile(
+	model string,
+	language string,
+	path string,
+	timeOut *int,
+	options *t.TranscriptionOptions,
+) (t.GroqTranscriptionResponse, error) {
+	if path == "" {
+		return t.GroqTranscriptionResponse{}, errors.New("Path must not be empty.")
+	}
+	return c.audio(&Call{
+		Operation: OperationTranscribe,
+		Model:     model,
+		TimeOut:   timeOut,
+		Language:  language,
+		AudioPath: path,
+		Options:   options,
+	})
+}
+
+func (c *groqClient) Translate(
+	model string,
+	audioURL *string,
+	audioBytes *[]byte,
+	timeOut *int,
+) (t.GroqTranscriptionResponse, error) {
+	return c.TranslateWithOptions(model, audioURL, audioBytes, timeOut, nil)
+}
+
+func (c *groqClient) TranslateWithOptions(
+	model string,
+	audioURL *string,
+	audioBytes *[]byte,
+	timeOut *int,
+	options *t.TranscriptionOptions,
+) (t.GroqTranscriptionResponse, error) {
+	if err := checkAudioSource(audioURL, audioBytes); err != nil {
+		return t.GroqTranscriptionResponse{}, err
+	}
+	return c.audio(&Call{
+		Operation:  OperationTranslate,
+		Model:      model,
+		TimeOut:    timeOut,
+		AudioURL:   audioURL,
+		AudioBytes: audioBytes,
+		Options:    options,
+	})
+}
+
+func (c *groqClient) TranslateReader(
+	model string,
+	audio io.Reader,
+	timeOut *int,
+	options *t.TranscriptionOptions,
+) (t.GroqTranscriptionResponse, error) {
+	if audio == nil {
+		return t.GroqTranscriptionResponse{}, errors.New("Audio must not be nil.")
+	}
+	return c.audio(&Call{
+		Operation: OperationTranslate,
+		Model:     model,
+		TimeOut:   timeOut,
+		Audio:     audio,
+		Options:   options,
+	})
+}
+
+func (c *groqClient) TranslateFile(
+	model string,
+	path string,
+	timeOut *int,
+	options *t.TranscriptionOptions,
+) (t.GroqTranscriptionResponse, error) {
+	if path == "" {
+		return t.GroqTranscriptionResponse{}, errors.New("Path must not be empty.")
+	}
+	return c.audio(&Call{
+		Operation: OperationTranslate,
+		Model:     model,
+		TimeOut:   timeOut,
+		AudioPath: path,
+		Options:   options,
+	})
+}
+
+func checkAudioSource(audioURL *string, audioBytes *[]byte) error {
 	if audioURL != nil && audioBytes != nil {
-		return t.GroqTranscriptionResponse{}, errors.New("Either use audioURL or audioBytes but not both.")
+		return errors.New("Either use audioURL or audioBytes but not both.")
 	}
 
 	if audioURL == nil && audioBytes == nil {
-		return t.GroqTranscriptionResponse{}, errors.New("Either audioURL or audioBytes must be provided.")
+		return errors.New("Either audioURL or audioBytes must be provided.")
 	}
+	return nil
+}
 
-	if audioURL != nil {
-		resp, err := h.TranscribeGroq(model, language, c.apiKey, audioURL, nil, timeOut)
-		return resp, err
-	} else {
-		// create temp file from bytes
-		tempFile, err := os.CreateTemp("", "audio.wav")
-		if err != nil {
-			return t.GroqTranscriptionResponse{}, err
+// audio runs the middleware chain around a transcription or translation.
+func (c *groqClient) audio(call *Call) (t.GroqTranscriptionResponse, error) {
+	handler := chain(c.config.Middleware, func(ctx context.Context, call *Call) (*Result, error) {
+		transcription, err := c.transcribe(ctx, call)
+		return &Result{Transcription: transcription}, err
+	})
+
+	call.System = SystemGroq
+	ctx := withCallState(context.Background(), call.Operation)
+	result, err := handler(ctx, call)
+	if result == nil {
+		return t.GroqTranscriptionResponse{}, err
+	}
+	return result.Transcription, err
+}
+
+// audioFile is an audio file sent in one request, a chunk or the whole audio.
+type audioFile struct {
+	data   []byte
+	name   string
+	offset float64
+
+	// Duration in seconds, 0 when the file is sent unchanged and it isn't known
+	seconds float64
+}
+
+// transcribe transcribes or translates the call's audio. Audio up to maxAudioFileSize in a
+// known format is sent unchanged, other audio is decoded to 16kHz mono while it is read
+// and split into WAV chunks below the upload limit.
+func (c *groqClient) transcribe(ctx context.Context, call *Call) (t.GroqTranscriptionResponse, error) {
+	if call.AudioURL != nil {
+		return h.TranscribeGroq(ctx, audioTask(call.Operation), call.Model, call.Language, call.AudioURL, nil, "", call.TimeOut, call.Options, c.post(call, 0))
+	}
+
+	var raw *t.PCMFormat
+	var split h.SplitOptions
+	if call.Options != nil {
+		raw = call.Options.RawPCM
+		split = h.SplitOptions{OnSilence: call.Options.SplitOnSilence, Overlap: call.Options.ChunkOverlap}
+	}
+
+	// whole is all of the audio when it fits in one file
+	input := h.AudioInput{Raw: raw}
+	var whole []byte
+	switch {
+	case call.AudioBytes != nil:
+		input.Reader = bytes.NewReader(*call.AudioBytes)
+		if len(*call.AudioBytes) <= maxAudioFileSize {
+			whole = *call.AudioBytes
 		}
-		defer os.Remove(tempFile.Name())
-		_, err = tempFile.Write(*audioBytes)
+	case call.AudioPath != "":
+		file, err := os.Open(call.AudioPath)
 		if err != nil {
 			return t.GroqTranscriptionResponse{}, err
 		}
-		chunkPaths, tempDir, err := h.SplitAudio(tempFile.Name(), 20*1024*1024)
+		defer file.Close()
+		input.Reader, input.Path = file, call.AudioPath
+		if info, err := file.Stat(); err == nil && info.Size() <= maxAudioFileSize && raw == nil {
+			if whole, err = io.ReadAll(file); err != nil {
+				return t.GroqTranscriptionResponse{}, err
+			}
+			input.Reader = bytes.NewReader(whole)
+		}
+	default:
+		head, err := io.ReadAll(io.LimitReader(call.Audio, maxAudioFileSize+1))
 		if err != nil {
 			return t.GroqTranscriptionResponse{}, err
 		}
-		defer os.RemoveAll(tempDir)
+		if len(head) <= maxAudioFileSize {
+			whole = head
+		}
+		input.Reader = io.MultiReader(bytes.NewReader(head), call.Audio)
+	}
+
+	if whole != nil && raw == nil {
+		if format := h.DetectAudioFormat(whole); format != h.AudioFormatUnknown {
+			files := make(chan audioFile, 1)
+			files <- audioFile{data: whole, name: "audio." + string(format)}
+			close(files)
+			return c.transcribeChunks(ctx, call, files, nil)
+		}
+	}
 
-		var transcriptions []string
-		var totalDuration float64
-		for _, chunkPath := range chunkPaths {
-			audioBytes, err := os.ReadFile(chunkPath)
-			resp, err := h.TranscribeGroq(model, language, c.apiKey, nil, &audioBytes, timeOut)
+	// The splitter is stopped when a chunk fails in a way every later chunk would too
+	splitCtx, stopSplit := context.WithCancelCause(ctx)
+	defer stopSplit(nil)
+	files := make(chan audioFile)
+	var splitErr error
+	emitted := 0
+	go func() {
+		defer close(files)
+		splitErr = h.SplitAudio(input, maxAudioFileSize, split, func(chunk h.AudioChunk) error {
+			select {
+			case files <- audioFile{data: chunk.WAV(), name: "audio.wav", offset: chunk.Offset, seconds: chunk.Seconds()}:
+				emitted++
+				return nil
+			case <-splitCtx.Done():
+				return context.Cause(splitCtx)
+			}
+		})
+	}()
+	transcription, err := c.transcribeChunks(ctx, call, files, stopSplit)
+	if splitErr == nil || errors.Is(splitErr, context.Cause(splitCtx)) {
+		return transcription, err
+	}
+
+	// The audio after the transcribed chunks couldn't be decoded, it is reported as one more failed chunk
+	missing := &t.ChunkError{Index: emitted, Err: splitErr}
+	var partial *t.PartialTranscriptionError
+	switch {
+	case errors.As(err, &partial):
+		partial.Failed = append(partial.Failed, missing)
+		partial.Total++
+		return transcription, partial
+	case err == nil && emitted > 0:
+		return transcription, &t.PartialTranscriptionError{Transcription: transcription, Failed: []*t.ChunkError{missing}, Total: emitted + 1}
+	}
+	return t.GroqTranscriptionResponse{}, splitErr
+}
+
+// fatalChunkError reports whether err fails every chunk of the audio, not just the one sent.
+func fatalChunkError(err error) bool {
+	var apiErr *t.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case 401, 403, 404:
+		return true
+	}
+	return false
+}
+
+// transcribeChunks transcribes the files with bounded concurrency as they are received
+// and merges them in order. Failed chunks are retried, if any still fail the merged
+// successful chunks are returned with a *t.PartialTranscriptionError.
+// stop, when set, is called with the first chunk error that would fail every chunk.
+func (c *groqClient) transcribeChunks(
+	ctx context.Context,
+	call *Call,
+	files <-chan audioFile,
+	stop context.CancelCauseFunc,
+) (t.GroqTranscriptionResponse, error) {
+	var options t.TranscriptionOptions
+	if call.Options != nil {
+		options = *call.Options
+	}
+	requestOptions := call.Options
+	wantWords := slices.Contains(options.TimestampGranularities, t.TimestampGranularityWord)
+	if options.ChunkOverlap > 0 && !wantWords {
+		// Overlapping text is removed using word timestamps
+		withWords := options
+		withWords.TimestampGranularities = []t.TimestampGranularityEnum{
+			t.TimestampGranularitySegment, t.TimestampGranularityWord,
+		}
+		requestOptions = &withWords
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = 4
+	}
+	if options.ChunkAttempts <= 0 {
+		options.ChunkAttempts = 3
+	}
+	if options.CarryOverPrompt {
+		// Each chunk needs the text of the previous one
+		options.Concurrency = 1
+	}
+
+	// Guarded by mu, the slices grow while chunks are transcribed
+	var mu sync.Mutex
+	var chunks []t.GroqTranscriptionResponse
+	var errs []error
+	var offsets []float64
+	completed := 0
+	previous := ""
+
+	sem := make(chan struct{}, options.Concurrency)
+	var wg sync.WaitGroup
+	for file := range files {
+		sem <- struct{}{}
+		mu.Lock()
+		i := len(chunks)
+		chunks = append(chunks, t.GroqTranscriptionResponse{})
+		errs = append(errs, nil)
+		offsets = append(offsets, file.offset)
+		mu.Unlock()
+
+		wg.Go(func() {
+			defer func() { <-sem }()
+
+			mu.Lock()
+			chunkOptions := h.ChunkOptions(requestOptions, previous)
+			mu.Unlock()
+			chunk, err := c.transcribeChunk(ctx, call, file, chunkOptions, options.ChunkAttempts)
+			// Failed chunks keep their place in the timeline
 			if err != nil {
-				return t.GroqTranscriptionResponse{}, err
+				chunk = t.GroqTranscriptionResponse{Duration: file.seconds}
+				if stop != nil && fatalChunkError(err) {
+					stop(err)
+				}
 			}
-			transcriptions = append(transcriptions, resp.Text)
-			totalDuration += resp.Duration
+
+			mu.Lock()
+			defer mu.Unlock()
+			chunks[i], errs[i] = chunk, err
+			previous = chunk.Text
+			completed++
+			if options.OnProgress != nil {
+				options.OnProgress(completed, len(chunks))
+			}
+		})
+	}
+	wg.Wait()
+
+	total := len(chunks)
+	merged := h.MergeTranscriptions(chunks, offsets)
+	if !wantWords {
+		merged.Words = nil
+	}
+	var failed []*t.ChunkError
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, &t.ChunkError{Index: i, Err: err})
+		}
+	}
+	if total > 0 && len(failed) == total {
+		return t.GroqTranscriptionResponse{}, failed[0].Err
+	}
+	if len(failed) > 0 {
+		return merged, &t.PartialTranscriptionError{Transcription: merged, Failed: failed, Total: total}
+	}
+	return merged, nil
+}
+
+// transcribeChunk sends a chunk until it succeeds, fails with an error that isn't transient
+// or maxAttempts is reached.
+func (c *groqClient) transcribeChunk(
+	ctx context.Context,
+	call *Call,
+	file audioFile,
+	options *t.TranscriptionOptions,
+	maxAttempts int,
+) (t.GroqTranscriptionResponse, error) {
+	post := c.post(call, file.seconds)
+	for attempt := 1; ; attempt++ {
+		resp, err := h.TranscribeGroq(ctx, audioTask(call.Operation), call.Model, call.Language, nil, &file.data, file.name, call.TimeOut, options, post)
+		if err == nil || attempt >= maxAttempts || !retryableChunkError(err) {
+			return resp, err
+		}
+
+		backoff := time.Duration(500*math.Pow(2, float64(attempt-1))) * time.Millisecond
+		var limitErr *ratelimit.LimitError
+		if errors.As(err, &limitErr) {
+			backoff = max(backoff, limitErr.RetryAfter)
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return resp, err
 		}
+	}
+}
 
-		return t.GroqTranscriptionResponse{
-			Text:     strings.Join(transcriptions, ""),
-			Duration: totalDuration,
-		}, nil
+// retryableChunkError reports whether sending a chunk again may succeed. Status errors are
+// only returned after TranscribeGroq retried rate limits and server errors itself,
+// and client errors such as 400 or 413 fail again with the same chunk.
+func retryableChunkError(err error) bool {
+	var timeoutErr *t.TimeoutError
+	var limitErr *ratelimit.LimitError
+	var netErr net.Error
+	return errors.As(err, &timeoutErr) || errors.As(err, &limitErr) || errors.As(err, &netErr)
+}
 
+func audioTask(operation OperationEnum) h.AudioTaskEnum {
+	if operation == OperationTranslate {
+		return h.AudioTaskTranslate
 	}
+	return h.AudioTaskTranscribe
+}
 
+// post returns the function sending the call's transcription requests through the
+// rate limiter and attempt middleware. audioSeconds is 0 when unknown, e.g. for audio URLs,
+// the limiter is then charged the duration from the response.
+func (c *groqClient) post(call *Call, audioSeconds float64) h.TranscribePost {
+	limitReq := ratelimit.Request{
+		APIKey:       c.apiKey,
+		Model:        call.Model,
+		AudioSeconds: audioSeconds,
+	}
+
+	return func(ctx context.Context, retry bool, contentType string, body []byte) (h.Response, error) {
+		if c.config.RateLimiter != nil {
+			if err := c.config.RateLimiter.Wait(ctx, limitReq); err != nil {
+				return h.Response{}, err
+			}
+		}
+
+		kind := AttemptPrimary
+		if retry {
+			kind = AttemptRetry
+		}
+		headers := h.MergeHeaders(map[string]string{
+			"Content-Type":  contentType,
+			"Authorization": "Bearer " + c.apiKey,
+		}, h.MergeHeaders(c.config.Headers, call.Headers))
+		resp, err := sendAttempt(ctx, c.config.AttemptMiddleware, c.config.HTTPClient, h.Deadlines{}, &Attempt{
+			Kind:   kind,
+			Model:  call.Model,
+			URL:    c.config.BaseURL + "/audio/" + string(audioTask(call.Operation)),
+			Header: headers,
+			Body:   body,
+		})
+
+		if c.config.RateLimiter != nil && err == nil {
+			obs := ratelimit.Observation{StatusCode: resp.StatusCode, Header: resp.Header}
+			if resp.StatusCode == 200 && audioSeconds == 0 {
+				var duration struct {
+					Duration float64 `json:"duration"`
+				}
+				if json.Unmarshal(resp.Body, &duration) == nil {
+					obs.AudioSeconds = duration.Duration
+				}
+			}
+			c.config.RateLimiter.Observe(limitReq, obs)
+		}
+		return resp, err
+	}
 }
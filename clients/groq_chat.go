@@ -0,0 +1,181 @@
+package clients
+
+import (
+	"context"
+	"encoding/json/v2"
+	"errors"
+	"time"
+
+	h "github.com/Floris22/go-llm/v2/internal/helpers"
+	t "github.com/Floris22/go-llm/v2/llmtypes"
+	"github.com/Floris22/go-llm/v2/ratelimit"
+)
+
+func (c *groqClient) GenerateText(
+	messages []t.MessageForLLM,
+	messageParts []t.PartMessageForLLM,
+	model string,
+	temperature *float64,
+	maxTokens *int,
+	timeOut *int,
+) (t.OpenRouterResponse, error) {
+	return c.chat(&Call{
+		Operation:    OperationGenerateText,
+		Messages:     messages,
+		MessageParts: messageParts,
+		Model:        model,
+		Temperature:  temperature,
+		MaxTokens:    maxTokens,
+		TimeOut:      timeOut,
+	})
+}
+
+func (c *groqClient) GenerateTools(
+	messages []t.MessageForLLM,
+	messageParts []t.PartMessageForLLM,
+	tools []t.ToolSchema,
+	model string,
+	temperature *float64,
+	maxTokens *int,
+	timeOut *int,
+) (t.OpenRouterResponse, error) {
+	return c.chat(&Call{
+		Operation:    OperationGenerateTools,
+		Messages:     messages,
+		MessageParts: messageParts,
+		Tools:        &tools,
+		Model:        model,
+		Temperature:  temperature,
+		MaxTokens:    maxTokens,
+		TimeOut:      timeOut,
+	})
+}
+
+func (c *groqClient) GenerateStructured(
+	messages []t.MessageForLLM,
+	messageParts []t.PartMessageForLLM,
+	schema t.StructuredOutputSchema,
+	model string,
+	temperature *float64,
+	maxTokens *int,
+	timeOut *int,
+) (t.OpenRouterResponse, error) {
+	return c.chat(&Call{
+		Operation:    OperationGenerateStructured,
+		Messages:     messages,
+		MessageParts: messageParts,
+		Schema:       &schema,
+		Model:        model,
+		Temperature:  temperature,
+		MaxTokens:    maxTokens,
+		TimeOut:      timeOut,
+	})
+}
+
+// chat runs the middleware chain around a chat completion.
+func (c *groqClient) chat(call *Call) (t.OpenRouterResponse, error) {
+	handler := chain(c.config.Middleware, func(ctx context.Context, call *Call) (*Result, error) {
+		response, err := c.generate(ctx, call)
+		return &Result{Response: response}, err
+	})
+
+	call.System = SystemGroq
+	ctx := withCallState(context.Background(), call.Operation)
+	result, err := handler(ctx, call)
+	if result == nil {
+		return t.OpenRouterResponse{}, err
+	}
+	return result.Response, err
+}
+
+// generate sends a chat completion request, retrying on rate limits and server errors.
+// Deadline errors are returned as *t.TimeoutError.
+func (c *groqClient) generate(ctx context.Context, call *Call) (t.OpenRouterResponse, error) {
+	timeoutValue := 15
+	if call.TimeOut != nil {
+		timeoutValue = *call.TimeOut
+	}
+	ctx, cancel := h.WithTotalTimeout(ctx, time.Duration(timeoutValue)*time.Second)
+	defer cancel()
+
+	if call.Model == "" {
+		return t.OpenRouterResponse{}, errors.New("No model provided.")
+	}
+	body, err := h.CreateGroqRequestBody(call.Messages, call.MessageParts, call.Model, call.Temperature, call.MaxTokens, call.Schema, call.Tools)
+	if err != nil {
+		return t.OpenRouterResponse{}, err
+	}
+	headers := h.MergeHeaders(map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer " + c.apiKey,
+	}, h.MergeHeaders(c.config.Headers, call.Headers))
+
+	kind := AttemptPrimary
+	send := func(ctx context.Context) (h.Response, error) {
+		resp, err := c.sendChat(ctx, kind, call.Model, headers, body)
+		kind = AttemptRetry
+		return resp, err
+	}
+
+	resp, err := send(ctx)
+	if err != nil {
+		return t.OpenRouterResponse{}, err
+	}
+	respBody, statusCode := resp.Body, resp.StatusCode
+	if statusCode != 200 {
+		if statusCode == 429 || statusCode >= 500 {
+			var timeoutErr *t.TimeoutError
+			respBody, err = h.DoGroqWithRetries(ctx, send)
+			if errors.As(err, &timeoutErr) || errors.Is(err, ratelimit.ErrRateLimited) {
+				return t.OpenRouterResponse{}, err
+			}
+			if err != nil {
+				return t.OpenRouterResponse{}, h.RetriesFailedError("Groq", 5, err, respBody)
+			}
+		} else {
+			return t.OpenRouterResponse{}, &t.APIError{API: "Groq", StatusCode: statusCode, Body: string(respBody), Attempts: 1}
+		}
+	}
+
+	var response t.OpenRouterResponse
+	err = json.Unmarshal(respBody, &response)
+	return response, err
+}
+
+// sendChat makes a single chat completion request, waiting for the rate limiter first if one is configured.
+func (c *groqClient) sendChat(
+	ctx context.Context,
+	kind AttemptKindEnum,
+	model string,
+	headers map[string]string,
+	body []byte,
+) (h.Response, error) {
+	limiter := c.config.RateLimiter
+	limitReq := ratelimit.Request{
+		APIKey: c.apiKey,
+		Model:  model,
+		Tokens: h.EstimateTokens(body),
+	}
+	if limiter != nil {
+		if err := limiter.Wait(ctx, limitReq); err != nil {
+			return h.Response{}, h.TimeoutCause(ctx, err)
+		}
+	}
+
+	resp, err := sendAttempt(ctx, c.config.AttemptMiddleware, c.config.HTTPClient, h.Deadlines{}, &Attempt{
+		Kind:   kind,
+		Model:  model,
+		URL:    c.config.BaseURL + "/chat/completions",
+		Header: headers,
+		Body:   body,
+	})
+
+	if limiter != nil && err == nil {
+		limiter.Observe(limitReq, ratelimit.Observation{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Tokens:     h.ParseResponseMeta(resp).Tokens,
+		})
+	}
+	return resp, err
+}
@@ -0,0 +1,169 @@
+package clients_test
+
+import (
+	"encoding/json/v2"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Floris22/go-llm/v2/clients"
+	"github.com/Floris22/go-llm/v2/llmtypes"
+	"github.com/Floris22/go-llm/v2/mockserver"
+)
+
+// silentPCM reads size bytes of silent 16kHz mono 16-bit PCM, then fails with err.
+type silentPCM struct {
+	size int64
+	read atomic.Int64
+	err  error
+}
+
+func (r *silentPCM) Read(p []byte) (int, error) {
+	remaining := r.size - r.read.Load()
+	if remaining <= 0 {
+		return 0, r.err
+	}
+	n := int(min(int64(len(p)), remaining))
+	clear(p[:n])
+	r.read.Add(int64(n))
+	return n, nil
+}
+
+func rawPCMOptions() *llmtypes.TranscriptionOptions {
+	return &llmtypes.TranscriptionOptions{
+		RawPCM:      &llmtypes.PCMFormat{SampleRate: 16000, Channels: 1, BitDepth: 16},
+		Concurrency: 1,
+	}
+}
+
+func TestTranscribeSplitFailsPartway(t *testing.T) {
+	server := mockserver.New()
+	defer server.Close()
+	client := clients.NewGroqClientWithConfig("key", clients.GroqConfig{BaseURL: server.GroqURL()})
+
+	// Two full chunks are split before the audio fails
+	readErr := errors.New("disk gone")
+	audio := &silentPCM{size: 45 << 20, err: readErr}
+	transcription, err := client.TranscribeReader("whisper", "", audio, nil, rawPCMOptions())
+
+	var partial *llmtypes.PartialTranscriptionError
+	if !errors.As(err, &partial) {
+		t.Fatalf("error = %v, want a *PartialTranscriptionError", err)
+	}
+	if !errors.Is(err, readErr) {
+		t.Errorf("error = %v, want it to wrap the read error", err)
+	}
+	if partial.Total != 3 || len(partial.Failed) != 1 || partial.Failed[0].Index != 2 {
+		t.Errorf("partial = %d failed of %d, first %v, want chunk 2 of 3 failed", len(partial.Failed), partial.Total, partial.Failed[0])
+	}
+	if got := strings.Count(transcription.Text, "mock transcription"); got != 2 {
+		t.Errorf("transcription has %d chunk texts, want 2: %q", got, transcription.Text)
+	}
+	if transcription.Text != partial.Transcription.Text {
+		t.Errorf("returned transcription differs from the one in the error")
+	}
+}
+
+func TestTranscribeStopsSplittingOnFatalError(t *testing.T) {
+	server := mockserver.New()
+	defer server.Close()
+	server.SetDefault(mockserver.RouteGroqTranscriptions, mockserver.Scenario{StatusCode: 401})
+	client := clients.NewGroqClientWithConfig("key", clients.GroqConfig{BaseURL: server.GroqURL()})
+
+	audio := &silentPCM{size: 400 << 20, err: io.EOF}
+	options := rawPCMOptions()
+	options.ChunkAttempts = 1
+	_, err := client.TranscribeReader("whisper", "", audio, nil, options)
+
+	var apiErr *llmtypes.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 401 {
+		t.Fatalf("error = %v, want the 401 API error", err)
+	}
+	if read := audio.read.Load(); read >= audio.size/2 {
+		t.Errorf("read %d MB of audio after the first chunk failed, want decoding to stop", read>>20)
+	}
+}
+
+func TestGroqChatMaxTokens(t *testing.T) {
+	limit := 100
+	tests := []struct {
+		name      string
+		maxTokens *int
+		want      any
+	}{
+		{name: "model default", maxTokens: nil, want: nil},
+		{name: "set", maxTokens: &limit, want: float64(100)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := mockserver.New()
+			defer server.Close()
+			client := clients.NewGroqClientWithConfig("key", clients.GroqConfig{BaseURL: server.GroqURL()})
+
+			content := "hello"
+			messages := []llmtypes.MessageForLLM{{Role: llmtypes.RoleUser, Content: &content}}
+			if _, err := client.GenerateText(messages, nil, "llama", nil, test.maxTokens, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			requests := server.RequestsFor(mockserver.RouteGroqChat)
+			if len(requests) != 1 {
+				t.Fatalf("got %d requests, want 1", len(requests))
+			}
+			var body map[string]any
+			if err := json.Unmarshal(requests[0].Body, &body); err != nil {
+				t.Fatal(err)
+			}
+			if got := body["max_tokens"]; got != test.want {
+				t.Errorf("max_tokens = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTranscribeChunkRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		replies      []int
+		wantRequests int
+		wantErr      bool
+	}{
+		{name: "bad request is not retried", replies: []int{400}, wantRequests: 1, wantErr: true},
+		{name: "too large is not retried", replies: []int{413}, wantRequests: 1, wantErr: true},
+		{name: "network error is retried", replies: []int{0, 200}, wantRequests: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status := test.replies[min(int(requests.Add(1)), len(test.replies))-1]
+				switch status {
+				case 0:
+					panic(http.ErrAbortHandler)
+				case 200:
+					w.Write([]byte(`{"text": " ok", "duration": 1}`))
+				default:
+					w.WriteHeader(status)
+					w.Write([]byte(`{"error": {"message": "rejected"}}`))
+				}
+			}))
+			defer server.Close()
+			client := clients.NewGroqClientWithConfig("key", clients.GroqConfig{BaseURL: server.URL})
+
+			audio := &silentPCM{size: 32000, err: io.EOF}
+			_, err := client.TranscribeReader("whisper", "", audio, nil, rawPCMOptions())
+			if (err != nil) != test.wantErr {
+				t.Errorf("err = %v, want error %v", err, test.wantErr)
+			}
+			if got := int(requests.Load()); got != test.wantRequests {
+				t.Errorf("sent %d requests, want %d", got, test.wantRequests)
+			}
+		})
+	}
+}
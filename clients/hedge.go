@@ -0,0 +1,153 @@
+package clients
+
+import (
+	"context"
+	"encoding/json/v2"
+	"errors"
+	"time"
+
+	h "github.com/Floris22/go-llm/v2/internal/helpers"
+	t "github.com/Floris22/go-llm/v2/llmtypes"
+)
+
+// HedgeConfig enables hedged requests: if the original request hasn't responded
+// after Delay, a second request is sent and the first successful response wins.
+// The other request is cancelled. Fallbacks to the retry model are not hedged.
+type HedgeConfig struct {
+	// How long to wait for the original request before sending the hedge request
+	Delay time.Duration
+
+	// Model for the hedge request, "" uses the same model as the original request
+	Model string
+
+	// Reasoning config for the hedge request, merged over the request's reasoning config
+	Reasoning *t.ReasoningConfig
+
+	// Provider config for the hedge request, merged over the request's provider config.
+	// E.g. set a different Order to hedge against another provider.
+	Provider *t.ProviderConfig
+}
+
+// errHedgeLost cancels the request that lost the race.
+var errHedgeLost = errors.New("hedged request lost")
+
+type hedgeAttempt struct {
+	kind  AttemptKindEnum
+	model string
+	body  []byte
+
+	// Set when the attempt is launched
+	start time.Time
+}
+
+type hedgeResult struct {
+	index    int
+	resp     h.Response
+	err      error
+	duration time.Duration
+}
+
+// sendHedged sends primary and, if it hasn't responded after the hedge delay, hedge.
+// It returns the first successful response, or the response of primary if both fail.
+func (c *openRouterClient) sendHedged(
+	ctx context.Context,
+	headers map[string]string,
+	deadlines h.Deadlines,
+	primary hedgeAttempt,
+	hedge hedgeAttempt,
+) (h.Response, *t.HedgeReport, error) {
+	var attempts []hedgeAttempt
+	var cancels []context.CancelCauseFunc
+	results := make(chan hedgeResult, 2)
+
+	launch := func(attempt hedgeAttempt) {
+		index := len(attempts)
+		start := time.Now()
+		attempt.start = start
+		attemptCtx, cancel := context.WithCancelCause(ctx)
+		attempts = append(attempts, attempt)
+		cancels = append(cancels, cancel)
+		go func() {
+			resp, err := c.send(attemptCtx, attempt.kind, attempt.model, headers, attempt.body, deadlines)
+			results <- hedgeResult{index: index, resp: resp, err: err, duration: time.Since(start)}
+		}()
+	}
+	defer func() {
+		for _, cancel := range cancels {
+			cancel(nil)
+		}
+	}()
+
+	launch(primary)
+	timer := time.NewTimer(c.config.Hedge.Delay)
+	defer timer.Stop()
+
+	completed := map[int]hedgeResult{}
+	for {
+		select {
+		case <-timer.C:
+			launch(hedge)
+			continue
+		case result := <-results:
+			completed[result.index] = result
+		}
+
+		winner := -1
+		for index, result := range completed {
+			if result.err == nil && result.resp.StatusCode == 200 {
+				winner = index
+			}
+		}
+		if winner == -1 {
+			// Keep waiting while a request is still running. If the original request
+			// failed before the hedge delay, or both failed, leave it to the fallback.
+			if len(completed) < len(attempts) {
+				continue
+			}
+			winner = 0
+		}
+
+		for index, cancel := range cancels {
+			if index != winner {
+				cancel(errHedgeLost)
+			}
+		}
+		report := hedgeReport(attempts, completed, winner)
+		result := completed[winner]
+		return result.resp, report, result.err
+	}
+}
+
+// hedgeReport describes every attempt, attempts missing from completed were cancelled.
+func hedgeReport(
+	attempts []hedgeAttempt,
+	completed map[int]hedgeResult,
+	winner int,
+) *t.HedgeReport {
+	report := &t.HedgeReport{Winner: winner}
+	for index, attempt := range attempts {
+		result, done := completed[index]
+		info := t.HedgeAttempt{
+			Model:     attempt.model,
+			Duration:  result.duration,
+			Cancelled: !done,
+		}
+		if !done {
+			info.Duration = time.Since(attempt.start)
+		}
+		if done && result.err == nil && result.resp.StatusCode == 200 {
+			var meta struct {
+				ID       string            `json:"id"`
+				Provider string            `json:"provider"`
+				Usage    t.OpenRouterUsage `json:"usage"`
+			}
+			if json.Unmarshal(result.resp.Body, &meta) == nil {
+				info.ID = meta.ID
+				info.Provider = meta.Provider
+				info.Usage = &meta.Usage
+			}
+		}
+		report.Attempts = append(report.Attempts, info)
+	}
+	return report
+}
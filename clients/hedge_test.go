@@ -0,0 +1,159 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Floris22/go-llm/v2/llmtypes"
+)
+
+// fakeUpstream answers attempts per model without sending them, after a latency.
+type fakeUpstream struct {
+	latency map[string]time.Duration
+	status  map[string]int
+
+	mu        sync.Mutex
+	cancelled map[string]error
+}
+
+func (f *fakeUpstream) middleware(AttemptHandler) AttemptHandler {
+	return func(ctx context.Context, attempt *Attempt) (*AttemptResponse, error) {
+		select {
+		case <-time.After(f.latency[attempt.Model]):
+		case <-ctx.Done():
+			f.mu.Lock()
+			f.cancelled[attempt.Model] = context.Cause(ctx)
+			f.mu.Unlock()
+			return nil, ctx.Err()
+		}
+
+		status := f.status[attempt.Model]
+		if status == 0 {
+			status = 200
+		}
+		body := `{"id": "gen-` + attempt.Model + `", "choices": [{"message": {"role": "assistant", "content": "` + attempt.Model + `"}}]}`
+		return &AttemptResponse{StatusCode: status, Body: []byte(body)}, nil
+	}
+}
+
+func TestHedge(t *testing.T) {
+	tests := []struct {
+		name          string
+		latency       map[string]time.Duration
+		status        map[string]int
+		wantContent   string
+		wantWinner    int
+		wantCancelled string
+		wantErr       bool
+	}{
+		{
+			name:          "primary wins",
+			latency:       map[string]time.Duration{"primary": 60 * time.Millisecond, "hedge": time.Second},
+			wantContent:   "primary",
+			wantWinner:    0,
+			wantCancelled: "hedge",
+		},
+		{
+			name:          "hedge wins",
+			latency:       map[string]time.Duration{"primary": time.Second, "hedge": 10 * time.Millisecond},
+			wantContent:   "hedge",
+			wantWinner:    1,
+			wantCancelled: "primary",
+		},
+		{
+			name:    "both fail",
+			latency: map[string]time.Duration{"primary": 40 * time.Millisecond, "hedge": 10 * time.Millisecond},
+			status:  map[string]int{"primary": 500, "hedge": 500},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			upstream := &fakeUpstream{latency: test.latency, status: test.status, cancelled: map[string]error{}}
+			client := NewOpenRouterClientWithConfig("key", OpenRouterConfig{
+				Model:             "primary",
+				Hedge:             &HedgeConfig{Delay: 20 * time.Millisecond, Model: "hedge"},
+				AttemptMiddleware: []AttemptMiddleware{upstream.middleware},
+			})
+
+			content := "hello"
+			messages := []llmtypes.MessageForLLM{{Role: llmtypes.RoleUser, Content: &content}}
+			response, err := client.GenerateText(messages, nil, "", nil, nil, nil, nil, nil, nil)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := response.Choices[0].Message.Content; got != test.wantContent {
+				t.Errorf("content = %q, want %q", got, test.wantContent)
+			}
+			if response.Hedge == nil || len(response.Hedge.Attempts) != 2 {
+				t.Fatalf("hedge report = %+v, want 2 attempts", response.Hedge)
+			}
+			if response.Hedge.Winner != test.wantWinner {
+				t.Errorf("winner = %d, want %d", response.Hedge.Winner, test.wantWinner)
+			}
+			if loser := response.Hedge.Attempts[1-test.wantWinner]; !loser.Cancelled {
+				t.Errorf("losing attempt not reported as cancelled: %+v", loser)
+			}
+
+			// The loser is cancelled before sendHedged returns, but its middleware may still be finishing
+			time.Sleep(20 * time.Millisecond)
+			upstream.mu.Lock()
+			defer upstream.mu.Unlock()
+			if cause := upstream.cancelled[test.wantCancelled]; !errors.Is(cause, errHedgeLost) {
+				t.Errorf("cancel cause of %s = %v, want errHedgeLost", test.wantCancelled, cause)
+			}
+		})
+	}
+}
+
+func TestAttemptHeadersNotShared(t *testing.T) {
+	upstream := &fakeUpstream{
+		latency:   map[string]time.Duration{"primary": 40 * time.Millisecond, "hedge": 40 * time.Millisecond, "retry": 0},
+		status:    map[string]int{"primary": 502, "hedge": 502},
+		cancelled: map[string]error{},
+	}
+	var mu sync.Mutex
+	var shared []string
+	setHeader := func(next AttemptHandler) AttemptHandler {
+		return func(ctx context.Context, attempt *Attempt) (*AttemptResponse, error) {
+			if _, ok := attempt.Header["X-Attempt"]; ok {
+				mu.Lock()
+				shared = append(shared, attempt.Model)
+				mu.Unlock()
+			}
+			attempt.Header["X-Attempt"] = string(attempt.Kind)
+			return next(ctx, attempt)
+		}
+	}
+
+	client := NewOpenRouterClientWithConfig("key", OpenRouterConfig{
+		Model:             "primary",
+		EnableRetry:       true,
+		RetryModel:        "retry",
+		Hedge:             &HedgeConfig{Delay: 10 * time.Millisecond, Model: "hedge"},
+		AttemptMiddleware: []AttemptMiddleware{setHeader, upstream.middleware},
+	})
+	content := "hello"
+	messages := []llmtypes.MessageForLLM{{Role: llmtypes.RoleUser, Content: &content}}
+	response, err := client.GenerateText(messages, nil, "", nil, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := response.Choices[0].Message.Content; got != "retry" {
+		t.Errorf("content = %q, want the retry model's response", got)
+	}
+	if len(shared) > 0 {
+		t.Errorf("attempts to %v saw a header set by another attempt's middleware", shared)
+	}
+}
@@ -0,0 +1,175 @@
+package clients
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	h "github.com/Floris22/go-llm/v2/internal/helpers"
+)
+
+// LogConfig enables structured logging of every call and HTTP request.
+// Request and response bodies are only logged at slog.LevelDebug.
+// API keys and Authorization headers are always redacted.
+type LogConfig struct {
+	// Defaults to slog.Default()
+	Logger *slog.Logger
+
+	// Level of the records of successful calls and requests, defaults to slog.LevelInfo.
+	Level slog.Level
+
+	// Level of the records of failed calls, defaults to slog.LevelError.
+	// Failed requests which may still be retried are logged at slog.LevelWarn.
+	ErrorLevel *slog.Level
+
+	// JSON fields whose values are redacted in logged bodies at any depth, e.g. "content" or "url".
+	RedactFields []string
+}
+
+// withLogging adds the logging middleware of config innermost, so the records show
+// the calls and requests as changed by other middleware.
+func withLogging(
+	config *LogConfig,
+	apiKey string,
+	middleware []Middleware,
+	attemptMiddleware []AttemptMiddleware,
+) ([]Middleware, []AttemptMiddleware) {
+	if config == nil {
+		return middleware, attemptMiddleware
+	}
+	l := newCallLogger(config, apiKey)
+	return slices.Concat(middleware, []Middleware{l.middleware}),
+		slices.Concat(attemptMiddleware, []AttemptMiddleware{l.attemptMiddleware})
+}
+
+type callLogger struct {
+	logger       *slog.Logger
+	level        slog.Level
+	errorLevel   slog.Level
+	redactFields []string
+	apiKey       string
+}
+
+func newCallLogger(config *LogConfig, apiKey string) *callLogger {
+	l := &callLogger{
+		logger:       config.Logger,
+		level:        config.Level,
+		errorLevel:   slog.LevelError,
+		redactFields: config.RedactFields,
+		apiKey:       apiKey,
+	}
+	if l.logger == nil {
+		l.logger = slog.Default()
+	}
+	if config.ErrorLevel != nil {
+		l.errorLevel = *config.ErrorLevel
+	}
+	return l
+}
+
+func (l *callLogger) middleware(next Handler) Handler {
+	return func(ctx context.Context, call *Call) (*Result, error) {
+		attrs := []slog.Attr{
+			slog.String("operation", string(call.Operation)),
+			slog.String("system", string(call.System)),
+			slog.String("model", call.Model),
+		}
+		l.logger.LogAttrs(ctx, l.level, "LLM call started", attrs...)
+
+		start := time.Now()
+		result, err := next(ctx, call)
+		attrs = append(attrs, slog.Duration("latency", time.Since(start)))
+
+		if err != nil {
+			secrets := l.secrets(call.Headers)
+			attrs = append(attrs, slog.String("error", h.Redact(err.Error(), secrets)))
+			l.logger.LogAttrs(ctx, l.errorLevel, "LLM call failed", attrs...)
+			return result, err
+		}
+
+		if result != nil {
+			switch call.Operation {
+			case OperationTranscribe, OperationTranslate:
+				attrs = append(attrs, slog.Float64("audio_seconds", result.Transcription.Duration))
+			default:
+				response := result.Response
+				attrs = append(attrs,
+					slog.String("response_model", response.Model),
+					slog.String("provider", response.Provider),
+					slog.Int("input_tokens", response.Usage.PromptTokens),
+					slog.Int("output_tokens", response.Usage.CompletionTokens),
+					slog.Int("cached_tokens", response.Usage.PromptTokensDetails.CachedTokens),
+				)
+				if response.Usage.Cost != nil {
+					attrs = append(attrs, slog.Float64("cost", *response.Usage.Cost))
+				}
+				if response.CacheHit {
+					attrs = append(attrs, slog.Bool("cache_hit", true))
+				}
+			}
+		}
+		l.logger.LogAttrs(ctx, l.level, "LLM call finished", attrs...)
+		return result, err
+	}
+}
+
+func (l *callLogger) attemptMiddleware(next AttemptHandler) AttemptHandler {
+	return func(ctx context.Context, attempt *Attempt) (*AttemptResponse, error) {
+		secrets := l.secrets(attempt.Header)
+		attrs := []slog.Attr{
+			slog.String("operation", string(attempt.Operation)),
+			slog.String("kind", string(attempt.Kind)),
+			slog.Int("attempt", attempt.Number),
+			slog.String("model", attempt.Model),
+			slog.String("url", h.Redact(attempt.URL, secrets)),
+		}
+
+		debug := l.logger.Enabled(ctx, slog.LevelDebug)
+		if debug && isJSON(attempt.Header["Content-Type"]) {
+			l.logger.LogAttrs(ctx, slog.LevelDebug, "LLM request body", append(attrs,
+				slog.String("body", h.RedactJSON(attempt.Body, l.redactFields, secrets)),
+			)...)
+		}
+
+		start := time.Now()
+		resp, err := next(ctx, attempt)
+		attrs = append(attrs, slog.Duration("latency", time.Since(start)))
+
+		if err != nil {
+			attrs = append(attrs, slog.String("error", h.Redact(err.Error(), secrets)))
+			l.logger.LogAttrs(ctx, slog.LevelWarn, "LLM request failed", attrs...)
+			return resp, err
+		}
+
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+		level := l.level
+		if resp.StatusCode >= 400 {
+			level = slog.LevelWarn
+		}
+		l.logger.LogAttrs(ctx, level, "LLM request finished", attrs...)
+
+		if debug {
+			l.logger.LogAttrs(ctx, slog.LevelDebug, "LLM response body", append(attrs,
+				slog.String("body", h.RedactJSON(resp.Body, l.redactFields, secrets)),
+			)...)
+		}
+		return resp, err
+	}
+}
+
+// secrets returns the client's API key and the credentials in the Authorization header.
+func (l *callLogger) secrets(headers map[string]string) []string {
+	secrets := []string{l.apiKey}
+	for key, value := range headers {
+		if strings.EqualFold(key, "Authorization") {
+			secrets = append(secrets, value, strings.TrimPrefix(value, "Bearer "))
+		}
+	}
+	return secrets
+}
+
+func isJSON(contentType string) bool {
+	return strings.HasPrefix(contentType, "application/json")
+}
@@ -0,0 +1,170 @@
+package clients
+
+import (
+	"context"
+	"io"
+	"maps"
+	"net/http"
+	"sync/atomic"
+
+	h "github.com/Floris22/go-llm/v2/internal/helpers"
+	t "github.com/Floris22/go-llm/v2/llmtypes"
+)
+
+type OperationEnum string
+
+const (
+	OperationGenerateText       OperationEnum = "generate_text"
+	OperationGenerateTools      OperationEnum = "generate_tools"
+	OperationGenerateStructured OperationEnum = "generate_structured"
+	OperationTranscribe         OperationEnum = "transcribe"
+	OperationTranslate          OperationEnum = "translate"
+)
+
+type SystemEnum string
+
+const (
+	SystemOpenRouter SystemEnum = "openrouter"
+	SystemGroq       SystemEnum = "groq"
+)
+
+// Call is a logical client call, e.g. one GenerateText or Transcribe.
+// Middleware may modify it before passing it on.
+type Call struct {
+	Operation OperationEnum
+
+	// API the call is sent to
+	System SystemEnum
+
+	// Extra headers for every request of the call, applied over the client's headers.
+	// E.g. set Authorization to use a different API key.
+	Headers map[string]string
+
+	// Model, TimeOut, Temperature and MaxTokens are set to the client's defaults when not given
+	Model       string
+	TimeOut     *int
+	Temperature *float64
+	MaxTokens   *int
+
+	// Chat completions only
+	Messages     []t.MessageForLLM
+	MessageParts []t.PartMessageForLLM
+	Schema       *t.StructuredOutputSchema
+	Tools        *[]t.ToolSchema
+
+	// OpenRouter only
+	Reasoning *t.ReasoningConfig
+	Provider  *t.ProviderConfig
+	Plugins   []t.Plugin
+
+	// Groq transcription and translation only, one of AudioURL, AudioBytes, Audio and AudioPath is set
+	Language   string
+	AudioURL   *string
+	AudioBytes *[]byte
+	Audio      io.Reader
+	AudioPath  string
+	Options    *t.TranscriptionOptions
+}
+
+// Result is the result of a Call. Only the field matching the call's operation is set.
+type Result struct {
+	Response      t.OpenRouterResponse
+	Transcription t.GroqTranscriptionResponse
+}
+
+// Handler handles a logical call, including its retries and fallbacks.
+type Handler func(ctx context.Context, call *Call) (*Result, error)
+
+// Middleware wraps a Handler, e.g. to log, redact or collect metrics for every call.
+type Middleware func(next Handler) Handler
+
+type AttemptKindEnum string
+
+const (
+	// The first request of a call
+	AttemptPrimary AttemptKindEnum = "primary"
+
+	// A retry of a failed request with the same model
+	AttemptRetry AttemptKindEnum = "retry"
+
+	// A request to the retry model after the original model failed
+	AttemptFallback AttemptKindEnum = "fallback"
+
+	// The second request of a hedged call
+	AttemptHedge AttemptKindEnum = "hedge"
+)
+
+// Attempt is a single HTTP request made during a call.
+// Attempt middleware may modify it before passing it on.
+type Attempt struct {
+	Operation OperationEnum
+	Kind      AttemptKindEnum
+
+	// Position of the attempt within its call, starting at 1
+	Number int
+
+	Model  string
+	URL    string
+	Header map[string]string
+	Body   []byte
+}
+
+// AttemptResponse is the HTTP response to an Attempt.
+type AttemptResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// AttemptHandler sends a single HTTP request.
+type AttemptHandler func(ctx context.Context, attempt *Attempt) (*AttemptResponse, error)
+
+// AttemptMiddleware wraps an AttemptHandler, it runs once for every HTTP request,
+// including retries, fallbacks and hedged requests.
+type AttemptMiddleware func(next AttemptHandler) AttemptHandler
+
+// chain wraps handler in middleware, the first middleware is the outermost.
+func chain[M ~func(H) H, H any](middleware []M, handler H) H {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+// callState tracks the attempts of a call, it is stored in the call's context.
+type callState struct {
+	operation OperationEnum
+	attempts  atomic.Int32
+}
+
+type callStateKey struct{}
+
+func withCallState(ctx context.Context, operation OperationEnum) context.Context {
+	return context.WithValue(ctx, callStateKey{}, &callState{operation: operation})
+}
+
+// sendAttempt sends attempt through the attempt middleware of the client.
+func sendAttempt(
+	ctx context.Context,
+	middleware []AttemptMiddleware,
+	client *http.Client,
+	deadlines h.Deadlines,
+	attempt *Attempt,
+) (h.Response, error) {
+	// Attempts of a call share their headers, middleware may modify its copy
+	attempt.Header = maps.Clone(attempt.Header)
+	if state, ok := ctx.Value(callStateKey{}).(*callState); ok {
+		attempt.Operation = state.operation
+		attempt.Number = int(state.attempts.Add(1))
+	}
+
+	handler := chain(middleware, func(ctx context.Context, attempt *Attempt) (*AttemptResponse, error) {
+		resp, err := h.PostReqWithDeadlines(ctx, attempt.URL, attempt.Header, attempt.Body, client, deadlines)
+		return &AttemptResponse{StatusCode: resp.StatusCode, Header: resp.Header, Body: resp.Body}, err
+	})
+	resp, err := handler(ctx, attempt)
+	if resp == nil {
+		return h.Response{}, err
+	}
+	return h.Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: resp.Body}, err
+}
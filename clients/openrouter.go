@@ -3,11 +3,16 @@ package clients
 import (
 	"context"
 	"encoding/json/v2"
-	"fmt"
+	"errors"
+	"net/http"
+	"sync"
 	"time"
 
+	"github.com/Floris22/go-llm/v2/cache"
+	"github.com/Floris22/go-llm/v2/circuitbreaker"
 	h "github.com/Floris22/go-llm/v2/internal/helpers"
 	t "github.com/Floris22/go-llm/v2/llmtypes"
+	"github.com/Floris22/go-llm/v2/ratelimit"
 )
 
 type OpenRouterClient interface {
@@ -20,6 +25,7 @@ type OpenRouterClient interface {
 		timeOut *int,
 		reasoning *t.ReasoningConfig,
 		provider *t.ProviderConfig,
+		plugins []t.Plugin,
 	) (t.OpenRouterResponse, error)
 
 	GenerateTools(
@@ -32,6 +38,7 @@ type OpenRouterClient interface {
 		timeOut *int,
 		reasoning *t.ReasoningConfig,
 		provider *t.ProviderConfig,
+		plugins []t.Plugin,
 	) (t.OpenRouterResponse, error)
 
 	GenerateStructured(
@@ -44,14 +51,103 @@ type OpenRouterClient interface {
 		timeOut *int,
 		reasoning *t.ReasoningConfig,
 		provider *t.ProviderConfig,
+		plugins []t.Plugin,
 	) (t.OpenRouterResponse, error)
 }
 
+// OpenRouterConfig holds client-wide defaults for every request.
+// Non-nil (or non-empty) parameters passed to a Generate call override these.
+// Reasoning and Provider are merged field by field, so a per-call ProviderConfig
+// that only sets Sort keeps the client's ZDR and DataCollection settings.
+// Plugins are merged by ID.
+type OpenRouterConfig struct {
+	// Used when a Generate call passes an empty model name
+	Model string
+
+	Temperature *float64
+	MaxTokens   *int
+
+	// Total timeout in seconds for a Generate call, including fallbacks. Defaults to 15.
+	TimeOut *int
+
+	// Maximum time to establish a connection for each request, zero means no limit.
+	ConnectTimeout time.Duration
+
+	// Maximum time from sending each request until the first response byte, zero means no limit.
+	// With reasoning models this includes the thinking time.
+	FirstTokenTimeout time.Duration
+
+	Reasoning *t.ReasoningConfig
+	Provider  *t.ProviderConfig
+	Plugins   []t.Plugin
+
+	// Extra headers sent with every request, e.g. HTTP-Referer and X-Title for app attribution.
+	// Authorization defaults to the API key.
+	Headers map[string]string
+
+	// Retry on a timeout, rate limit or model failure with RetryModel.
+	// If RetryModel is "" we set openai/gpt-oss-120b:nitro as default and RetryModelReasoningConfig to nil.
+	// The fallback request uses the same defaults and overrides as the original request,
+	// except for the model and reasoning config.
+	EnableRetry               bool
+	RetryModel                string
+	RetryModelReasoningConfig *t.ReasoningConfig
+
+	// HTTP client used for all requests, defaults to http.DefaultClient.
+	// E.g. use a cassette.Recorder's client to record and replay requests in tests.
+	HTTPClient *http.Client
+
+	// API base URL, defaults to https://openrouter.ai/api/v1.
+	// E.g. use a mockserver.Server's OpenRouterURL in integration tests.
+	BaseURL string
+
+	// Optional client-side rate limiter, consulted before every request including retries.
+	// Can be shared with other clients.
+	RateLimiter ratelimit.Limiter
+
+	// Middleware around every logical call, including its retries and fallbacks.
+	// The first middleware is the outermost.
+	Middleware []Middleware
+
+	// Middleware around every HTTP request, including retries, fallbacks and hedged requests.
+	// The first middleware is the outermost.
+	AttemptMiddleware []AttemptMiddleware
+
+	// Optional structured logging of every call and request, see LogConfig
+	Logging *LogConfig
+
+	// Optional response cache, see ResponseCacheConfig
+	Cache *ResponseCacheConfig
+
+	// Optional hedging of the original request, see HedgeConfig.
+	// The response's Hedge field reports the requests that were made.
+	Hedge *HedgeConfig
+
+	// Optional circuit breaker keyed by model and upstream provider.
+	// While the circuit of a model is open its requests go straight to the retry model,
+	// or fail with a *circuitbreaker.OpenError if retry is disabled.
+	// Providers with an open circuit are added to ProviderConfig.Ignore, half-open providers
+	// are only left out of it for as many calls as the breaker allows probes.
+	CircuitBreaker *circuitbreaker.Breaker
+}
+
+// ResponseCacheConfig caches successful responses by a hash of the request body,
+// which covers the model, messages, parameters, schema, tools, provider and plugins.
+// Responses from the retry model are not cached.
+type ResponseCacheConfig struct {
+	Store cache.Store
+
+	// How long responses are cached, zero means forever
+	TTL time.Duration
+
+	// Also cache requests with a non-zero temperature. Without it only requests
+	// with an explicit temperature of 0 are cached, the default temperature is 0.7.
+	Force bool
+}
+
 type openRouterClient struct {
-	apiKey                    string
-	retryModel                string
-	retryModelReasoningConfig *t.ReasoningConfig
-	enableRetry               bool
+	apiKey string
+	config OpenRouterConfig
 }
 
 // Creates a new open router OpenRouterClient.
@@ -64,15 +160,29 @@ func NewOpenRouterClient(
 	retryModel string,
 	retryModelReasoningConfig *t.ReasoningConfig,
 ) OpenRouterClient {
-	if enableRetry && retryModel == "" {
-		retryModel = "openai/gpt-oss-120b:nitro"
-		retryModelReasoningConfig = nil
+	return NewOpenRouterClientWithConfig(apiKey, OpenRouterConfig{
+		EnableRetry:               enableRetry,
+		RetryModel:                retryModel,
+		RetryModelReasoningConfig: retryModelReasoningConfig,
+	})
+}
+
+// Creates a new open router OpenRouterClient with client-wide defaults.
+// See OpenRouterConfig for how defaults and per-call parameters are combined.
+func NewOpenRouterClientWithConfig(apiKey string, config OpenRouterConfig) OpenRouterClient {
+	if config.EnableRetry && config.RetryModel == "" {
+		config.RetryModel = "openai/gpt-oss-120b:nitro"
+		config.RetryModelReasoningConfig = nil
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = "https://openrouter.ai/api/v1"
 	}
+	config.Middleware, config.AttemptMiddleware = withLogging(
+		config.Logging, apiKey, config.Middleware, config.AttemptMiddleware,
+	)
 	return &openRouterClient{
-		apiKey:                    apiKey,
-		retryModel:                retryModel,
-		enableRetry:               enableRetry,
-		retryModelReasoningConfig: retryModelReasoningConfig,
+		apiKey: apiKey,
+		config: config,
 	}
 }
 
@@ -85,149 +195,322 @@ func (c *openRouterClient) GenerateText(
 	timeOut *int,
 	reasoning *t.ReasoningConfig,
 	provider *t.ProviderConfig,
+	plugins []t.Plugin,
+) (t.OpenRouterResponse, error) {
+	return c.call(&Call{
+		System:       SystemOpenRouter,
+		Operation:    OperationGenerateText,
+		Messages:     messages,
+		MessageParts: messageParts,
+		Model:        model,
+		Temperature:  temperature,
+		MaxTokens:    maxTokens,
+		TimeOut:      timeOut,
+		Reasoning:    reasoning,
+		Provider:     provider,
+		Plugins:      plugins,
+	})
+}
+
+func (c *openRouterClient) GenerateTools(
+	messages []t.MessageForLLM,
+	messageParts []t.PartMessageForLLM,
+	tools []t.ToolSchema,
+	model string,
+	temperature *float64,
+	maxTokens *int,
+	timeOut *int,
+	reasoning *t.ReasoningConfig,
+	provider *t.ProviderConfig,
+	plugins []t.Plugin,
 ) (t.OpenRouterResponse, error) {
+	return c.call(&Call{
+		System:       SystemOpenRouter,
+		Operation:    OperationGenerateTools,
+		Messages:     messages,
+		MessageParts: messageParts,
+		Tools:        &tools,
+		Model:        model,
+		Temperature:  temperature,
+		MaxTokens:    maxTokens,
+		TimeOut:      timeOut,
+		Reasoning:    reasoning,
+		Provider:     provider,
+		Plugins:      plugins,
+	})
+}
+
+func (c *openRouterClient) GenerateStructured(
+	messages []t.MessageForLLM,
+	messageParts []t.PartMessageForLLM,
+	schema t.StructuredOutputSchema,
+	model string,
+	temperature *float64,
+	maxTokens *int,
+	timeOut *int,
+	reasoning *t.ReasoningConfig,
+	provider *t.ProviderConfig,
+	plugins []t.Plugin,
+) (t.OpenRouterResponse, error) {
+	return c.call(&Call{
+		System:       SystemOpenRouter,
+		Operation:    OperationGenerateStructured,
+		Messages:     messages,
+		MessageParts: messageParts,
+		Schema:       &schema,
+		Model:        model,
+		Temperature:  temperature,
+		MaxTokens:    maxTokens,
+		TimeOut:      timeOut,
+		Reasoning:    reasoning,
+		Provider:     provider,
+		Plugins:      plugins,
+	})
+}
+
+// call applies the client's scalar defaults to call and runs the middleware chain around generate.
+func (c *openRouterClient) call(call *Call) (t.OpenRouterResponse, error) {
+	if call.Model == "" {
+		call.Model = c.config.Model
+	}
+	call.Temperature = h.FirstNonNil(call.Temperature, c.config.Temperature)
+	call.MaxTokens = h.FirstNonNil(call.MaxTokens, c.config.MaxTokens)
+	call.TimeOut = h.FirstNonNil(call.TimeOut, c.config.TimeOut)
+
+	handler := chain(c.config.Middleware, func(ctx context.Context, call *Call) (*Result, error) {
+		response, err := c.generate(ctx, call)
+		return &Result{Response: response}, err
+	})
+
+	ctx := withCallState(context.Background(), call.Operation)
+	result, err := handler(ctx, call)
+	if result == nil {
+		return t.OpenRouterResponse{}, err
+	}
+	return result.Response, err
+}
+
+// generate applies the client defaults to req, sends it and falls back to the
+// retry model on a timeout, rate limit or model failure if retry is enabled.
+// Deadline errors are returned as *t.TimeoutError.
+func (c *openRouterClient) generate(ctx context.Context, req *Call) (t.OpenRouterResponse, error) {
 	timeoutValue := 15
-	if timeOut != nil {
+	if timeOut := h.FirstNonNil(req.TimeOut, c.config.TimeOut); timeOut != nil {
 		timeoutValue = *timeOut
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutValue)*time.Second)
+	ctx, cancel := h.WithTotalTimeout(ctx, time.Duration(timeoutValue)*time.Second)
 	defer cancel()
 
-	headers := map[string]string{
+	deadlines := h.Deadlines{
+		Connect:   c.config.ConnectTimeout,
+		FirstByte: c.config.FirstTokenTimeout,
+	}
+
+	model := req.Model
+	if model == "" {
+		model = c.config.Model
+	}
+	if model == "" {
+		return t.OpenRouterResponse{}, errors.New("No model provided and no default model configured.")
+	}
+	temperature := h.FirstNonNil(req.Temperature, c.config.Temperature)
+	maxTokens := h.FirstNonNil(req.MaxTokens, c.config.MaxTokens)
+	reasoning := h.MergeReasoningConfig(c.config.Reasoning, req.Reasoning)
+	provider := h.MergeProviderConfig(c.config.Provider, req.Provider)
+	if breaker := c.config.CircuitBreaker; breaker != nil {
+		rejected, probes := breaker.AllowPrefix(circuitbreaker.ProviderKey(""))
+		provider = h.IgnoreProviders(provider, rejected)
+		if len(probes) > 0 {
+			pending := newProviderProbes(breaker, probes)
+			defer pending.release()
+			ctx = context.WithValue(ctx, providerProbesKey{}, pending)
+		}
+	}
+	plugins := h.MergePlugins(c.config.Plugins, req.Plugins)
+
+	headers := h.MergeHeaders(map[string]string{
 		"Content-Type":  "application/json",
 		"Authorization": "Bearer " + c.apiKey,
-	}
+	}, h.MergeHeaders(c.config.Headers, req.Headers))
 
-	body, err := h.CreateRequestBody(messages, messageParts, model, temperature, maxTokens, nil, nil, reasoning, provider)
+	body, err := h.CreateRequestBody(req.Messages, req.MessageParts, model, temperature, maxTokens, req.Schema, req.Tools, reasoning, provider, plugins)
 	if err != nil {
 		return t.OpenRouterResponse{}, err
 	}
 
-	respBody, statusCode, err := h.PostReq(
-		ctx, "https://openrouter.ai/api/v1/chat/completions", headers, body, nil,
-	)
-	if err != nil {
+	var cacheKey string
+	if c.config.Cache != nil && (c.config.Cache.Force || (temperature != nil && *temperature == 0)) {
+		cacheKey, err = h.CacheKey(body)
+		if err != nil {
+			return t.OpenRouterResponse{}, err
+		}
+		if cached, ok, err := c.config.Cache.Store.Get(cacheKey); err == nil && ok {
+			var response t.OpenRouterResponse
+			if err := json.Unmarshal(cached, &response); err == nil {
+				response.CacheHit = true
+				return response, nil
+			}
+		}
+	}
+
+	var resp h.Response
+	var hedgeReport *t.HedgeReport
+	if hedge := c.config.Hedge; hedge != nil {
+		hedgeModel := model
+		hedgeReasoning := h.MergeReasoningConfig(reasoning, hedge.Reasoning)
+		if hedge.Model != "" {
+			hedgeModel = hedge.Model
+		}
+		hedgeBody, bodyErr := h.CreateRequestBody(req.Messages, req.MessageParts, hedgeModel, temperature, maxTokens, req.Schema, req.Tools, hedgeReasoning, h.MergeProviderConfig(provider, hedge.Provider), plugins)
+		if bodyErr != nil {
+			return t.OpenRouterResponse{}, bodyErr
+		}
+		resp, hedgeReport, err = c.sendHedged(ctx, headers, deadlines,
+			hedgeAttempt{kind: AttemptPrimary, model: model, body: body},
+			hedgeAttempt{kind: AttemptHedge, model: hedgeModel, body: hedgeBody},
+		)
+	} else {
+		resp, err = c.send(ctx, AttemptPrimary, model, headers, body, deadlines)
+	}
+	var timeoutErr *t.TimeoutError
+	if errors.Is(err, ratelimit.ErrRateLimited) {
 		return t.OpenRouterResponse{}, err
 	}
+	canFallBack := (errors.As(err, &timeoutErr) && timeoutErr.Phase != t.TimeoutPhaseTotal) || errors.Is(err, circuitbreaker.ErrOpen)
+	if err != nil && !(canFallBack && c.config.EnableRetry) {
+		return t.OpenRouterResponse{}, err
+	}
+	respBody, statusCode := resp.Body, resp.StatusCode
 	if statusCode != 200 {
 		// 408 == request timed out, 429 == rate limited, 502 model down or invalid response
-		if (statusCode == 408 || statusCode == 429 || statusCode == 502) && c.enableRetry {
-			body, err := h.CreateRequestBody(messages, messageParts, c.retryModel, temperature, maxTokens, nil, nil, c.retryModelReasoningConfig, nil)
+		// 0 == connect or first token deadline expired, or circuit open
+		if (statusCode == 0 || statusCode == 408 || statusCode == 429 || statusCode == 502) && c.config.EnableRetry {
+			body, err := h.CreateRequestBody(req.Messages, req.MessageParts, c.config.RetryModel, temperature, maxTokens, req.Schema, req.Tools, c.config.RetryModelReasoningConfig, provider, plugins)
 			if err != nil {
 				return t.OpenRouterResponse{}, err
 			}
-			respBody, err = h.DoReqWithRetries(ctx, headers, body)
+			respBody, err = h.DoReqWithRetries(ctx, func(ctx context.Context) (h.Response, error) {
+				return c.send(ctx, AttemptFallback, c.config.RetryModel, headers, body, deadlines)
+			})
+			if errors.As(err, &timeoutErr) || errors.Is(err, ratelimit.ErrRateLimited) || errors.Is(err, circuitbreaker.ErrOpen) {
+				return t.OpenRouterResponse{}, err
+			}
 			if err != nil {
-				return t.OpenRouterResponse{}, fmt.Errorf("OpenRouter API failed retry after 3 attempts: %s", string(respBody))
+				return t.OpenRouterResponse{}, h.RetriesFailedError("OpenRouter", 3, err, respBody)
 			}
 		} else {
-			return t.OpenRouterResponse{}, fmt.Errorf("OpenRouter API returned status code %d with error: %s", statusCode, string(respBody))
+			return t.OpenRouterResponse{}, &t.APIError{API: "OpenRouter", StatusCode: statusCode, Body: string(respBody), Attempts: 1}
 		}
 	}
 
 	var response t.OpenRouterResponse
 	err = json.Unmarshal(respBody, &response)
-	if err != nil {
-		return t.OpenRouterResponse{}, err
+	if err == nil && cacheKey != "" && statusCode == 200 {
+		// Cache errors only cost a cache miss later, the response is still valid
+		c.config.Cache.Store.Set(cacheKey, respBody, c.config.Cache.TTL)
 	}
-
-	return response, nil
+	response.Hedge = hedgeReport
+	return response, err
 }
 
-func (c *openRouterClient) GenerateTools(
-	messages []t.MessageForLLM,
-	messageParts []t.PartMessageForLLM,
-	tools []t.ToolSchema,
+// send makes a single request attempt, waiting for the rate limiter first if one is configured.
+// The outcome is recorded in the circuit breaker, if the circuit of model is open
+// a *circuitbreaker.OpenError is returned without sending anything.
+func (c *openRouterClient) send(
+	ctx context.Context,
+	kind AttemptKindEnum,
 	model string,
-	temperature *float64,
-	maxTokens *int,
-	timeOut *int,
-	reasoning *t.ReasoningConfig,
-	provider *t.ProviderConfig,
-) (t.OpenRouterResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(15)*time.Second)
-	defer cancel()
-
-	headers := map[string]string{
-		"Content-Type":  "application/json",
-		"Authorization": "Bearer " + c.apiKey,
+	headers map[string]string,
+	body []byte,
+	deadlines h.Deadlines,
+) (h.Response, error) {
+	limiter := c.config.RateLimiter
+	limitReq := ratelimit.Request{
+		APIKey: c.apiKey,
+		Model:  model,
+		Tokens: h.EstimateTokens(body),
+	}
+	if limiter != nil {
+		if err := limiter.Wait(ctx, limitReq); err != nil {
+			return h.Response{}, h.TimeoutCause(ctx, err)
+		}
 	}
 
-	body, err := h.CreateRequestBody(messages, messageParts, model, temperature, maxTokens, nil, &tools, reasoning, provider)
-	if err != nil {
-		return t.OpenRouterResponse{}, err
+	breaker := c.config.CircuitBreaker
+	if breaker != nil {
+		if err := breaker.Allow(circuitbreaker.ModelKey(model)); err != nil {
+			return h.Response{}, err
+		}
 	}
 
-	respBody, statusCode, err := h.PostReq(
-		ctx, "https://openrouter.ai/api/v1/chat/completions", headers, body, nil,
-	)
-	if err != nil {
-		return t.OpenRouterResponse{}, err
+	resp, err := sendAttempt(ctx, c.config.AttemptMiddleware, c.config.HTTPClient, deadlines, &Attempt{
+		Kind:   kind,
+		Model:  model,
+		URL:    c.config.BaseURL + "/chat/completions",
+		Header: headers,
+		Body:   body,
+	})
+	meta := h.ParseResponseMeta(resp)
+
+	if limiter != nil && err == nil {
+		limiter.Observe(limitReq, ratelimit.Observation{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Tokens:     meta.Tokens,
+		})
 	}
-	if statusCode != 200 {
-		if (statusCode == 408 || statusCode == 429 || statusCode == 502) && c.enableRetry {
-			body, err := h.CreateRequestBody(messages, messageParts, c.retryModel, temperature, maxTokens, nil, &tools, c.retryModelReasoningConfig, nil)
-			respBody, err = h.DoReqWithRetries(ctx, headers, body)
-			if err != nil {
-				return t.OpenRouterResponse{}, fmt.Errorf("OpenRouter API failed retry after 3 attempts: %s", string(respBody))
+
+	if breaker != nil && err != nil && errors.Is(context.Cause(ctx), errHedgeLost) {
+		breaker.Release(circuitbreaker.ModelKey(model))
+	} else if breaker != nil {
+		// Only timeouts, rate limits and server errors count as failures,
+		// other errors are caused by the request itself
+		failed := err != nil || resp.StatusCode == 408 || resp.StatusCode == 429 || resp.StatusCode >= 500
+		record := breaker.Success
+		if failed {
+			record = breaker.Failure
+		}
+		record(circuitbreaker.ModelKey(model))
+		if meta.Provider != "" {
+			record(circuitbreaker.ProviderKey(meta.Provider))
+			if pending, ok := ctx.Value(providerProbesKey{}).(*providerProbes); ok {
+				pending.resolve(meta.Provider)
 			}
-		} else {
-			return t.OpenRouterResponse{}, fmt.Errorf("OpenRouter API returned status code %d with error: %s", statusCode, string(respBody))
 		}
 	}
+	return resp, err
+}
 
-	var response t.OpenRouterResponse
-	err = json.Unmarshal(respBody, &response)
-	if err != nil {
-		return t.OpenRouterResponse{}, err
-	}
+// providerProbes are the half-open provider circuits a call may probe. A probe is resolved by the
+// first response from its provider, the probes of providers that didn't respond are released.
+type providerProbes struct {
+	breaker *circuitbreaker.Breaker
 
-	return response, nil
+	mu      sync.Mutex
+	pending map[string]bool
 }
 
-func (c *openRouterClient) GenerateStructured(
-	messages []t.MessageForLLM,
-	messageParts []t.PartMessageForLLM,
-	schema t.StructuredOutputSchema,
-	model string,
-	temperature *float64,
-	maxTokens *int,
-	timeOut *int,
-	reasoning *t.ReasoningConfig,
-	provider *t.ProviderConfig,
-) (t.OpenRouterResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(15)*time.Second)
-	defer cancel()
+type providerProbesKey struct{}
 
-	headers := map[string]string{
-		"Content-Type":  "application/json",
-		"Authorization": "Bearer " + c.apiKey,
+func newProviderProbes(breaker *circuitbreaker.Breaker, providers []string) *providerProbes {
+	pending := map[string]bool{}
+	for _, provider := range providers {
+		pending[provider] = true
 	}
+	return &providerProbes{breaker: breaker, pending: pending}
+}
 
-	body, err := h.CreateRequestBody(messages, messageParts, model, temperature, maxTokens, &schema, nil, reasoning, provider)
-	if err != nil {
-		return t.OpenRouterResponse{}, err
-	}
+func (p *providerProbes) resolve(provider string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.pending, provider)
+}
 
-	respBody, statusCode, err := h.PostReq(
-		ctx, "https://openrouter.ai/api/v1/chat/completions", headers, body, nil,
-	)
-	if err != nil {
-		return t.OpenRouterResponse{}, err
+func (p *providerProbes) release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for provider := range p.pending {
+		p.breaker.Release(circuitbreaker.ProviderKey(provider))
 	}
-	if statusCode != 200 {
-		if (statusCode == 408 || statusCode == 429 || statusCode == 502) && c.enableRetry {
-			body, err := h.CreateRequestBody(messages, messageParts, c.retryModel, temperature, maxTokens, &schema, nil, c.retryModelReasoningConfig, nil)
-			respBody, err = h.DoReqWithRetries(ctx, headers, body)
-			if err != nil {
-				return t.OpenRouterResponse{}, fmt.Errorf("OpenRouter API failed retry after 3 attempts: %s", string(respBody))
-			}
-		} else {
-			return t.OpenRouterResponse{}, fmt.Errorf("OpenRouter API returned status code %d with error: %s", statusCode, string(respBody))
-		}
-	}
-
-	var response t.OpenRouterResponse
-	err = json.Unmarshal(respBody, &response)
-	return response, err
+	p.pending = nil
 }
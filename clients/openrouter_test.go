@@ -0,0 +1,78 @@
+package clients_test
+
+import (
+	"encoding/json/v2"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Floris22/go-llm/v2/circuitbreaker"
+	"github.com/Floris22/go-llm/v2/clients"
+	"github.com/Floris22/go-llm/v2/llmtypes"
+	"github.com/Floris22/go-llm/v2/mockserver"
+)
+
+func TestProviderCircuitProbes(t *testing.T) {
+	server := mockserver.New()
+	defer server.Close()
+	breaker := circuitbreaker.New(circuitbreaker.Config{FailureThreshold: 1, OpenDuration: 20 * time.Millisecond})
+	client := clients.NewOpenRouterClientWithConfig("key", clients.OpenRouterConfig{
+		Model:          "test/model",
+		BaseURL:        server.OpenRouterURL(),
+		CircuitBreaker: breaker,
+	})
+
+	// ignored sends a request and returns the providers it ignored
+	ignored := func() []string {
+		t.Helper()
+		content := "hello"
+		messages := []llmtypes.MessageForLLM{{Role: llmtypes.RoleUser, Content: &content}}
+		if _, err := client.GenerateText(messages, nil, "", nil, nil, nil, nil, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+		requests := server.RequestsFor(mockserver.RouteChatCompletions)
+		var body struct {
+			Provider *llmtypes.ProviderConfig `json:"provider"`
+		}
+		if err := json.Unmarshal(requests[len(requests)-1].Body, &body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Provider == nil || body.Provider.Ignore == nil {
+			return nil
+		}
+		providers := *body.Provider.Ignore
+		slices.Sort(providers)
+		return providers
+	}
+
+	// The mock server's responses come from provider "Mock"
+	breaker.Failure(circuitbreaker.ProviderKey("Mock"))
+	breaker.Failure(circuitbreaker.ProviderKey("Other"))
+	if got := ignored(); !slices.Equal(got, []string{"Mock", "Other"}) {
+		t.Fatalf("ignored = %v, want the open providers", got)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	// A concurrent call holds the only probe of Other
+	if _, probes := breaker.AllowPrefix(circuitbreaker.ProviderKey("Other")); len(probes) != 1 {
+		t.Fatalf("probes = %v, want a probe of Other", probes)
+	}
+	if got := ignored(); !slices.Equal(got, []string{"Other"}) {
+		t.Fatalf("ignored = %v, want Other ignored while its probe is taken and Mock probed", got)
+	}
+	if state := breaker.State(circuitbreaker.ProviderKey("Mock")); state != circuitbreaker.StateClosed {
+		t.Errorf("Mock circuit = %s after a successful probe, want closed", state)
+	}
+
+	// A probe not answered by its provider is given back when the call ends
+	breaker.Release(circuitbreaker.ProviderKey("Other"))
+	if got := ignored(); len(got) != 0 {
+		t.Fatalf("ignored = %v, want Other probed", got)
+	}
+	if state := breaker.State(circuitbreaker.ProviderKey("Other")); state != circuitbreaker.StateHalfOpen {
+		t.Errorf("Other circuit = %s, want half open", state)
+	}
+	if _, probes := breaker.AllowPrefix(circuitbreaker.ProviderKey("Other")); len(probes) != 1 {
+		t.Errorf("probes = %v, want the probe of Other released", probes)
+	}
+}
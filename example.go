@@ -45,6 +45,7 @@ func main() {
 		os.Getenv("OPENROUTER_API_KEY"),
 		true,
 		"", // default openai gpt oss 120b
+		nil,
 	)
 
 	var messages []llmtypes.MessageForLLM
@@ -74,6 +75,7 @@ func main() {
 		// },
 		nil,
 		nil,
+		nil,
 	)
 	if err != nil {
 		panic(err)
@@ -150,6 +152,7 @@ var structuredOutputExample = llmtypes.StructuredOutputSchema{
 // 			MaxTokens: pti(0),
 // 		},
 // 		nil,
+// 		nil,
 // 	)
 // 	if err != nil {
 // 		panic(err)
@@ -0,0 +1,318 @@
+package fakes
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Floris22/go-llm/v2/clients"
+	t "github.com/Floris22/go-llm/v2/llmtypes"
+)
+
+var _ clients.GroqClient = (*FakeGroqClient)(nil)
+
+// GroqCall is a call received by a FakeGroqClient.
+type GroqCall struct {
+	// "Transcribe", "TranscribeWithOptions", "TranscribeReader", "TranscribeFile",
+	// "Translate", "TranslateWithOptions", "TranslateReader" or "TranslateFile"
+	Method string
+
+	Model      string
+	Language   string
+	AudioURL   *string
+	AudioBytes *[]byte
+	Audio      io.Reader
+	AudioPath  string
+	TimeOut    *int
+	Options    *t.TranscriptionOptions
+}
+
+// GroqReply is a scripted reply of a FakeGroqClient.
+type GroqReply struct {
+	Response t.GroqTranscriptionResponse
+	Err      error
+
+	// Simulated time before the reply is returned
+	Latency time.Duration
+}
+
+// TranscriptionReply returns a reply with the given text and duration in seconds.
+func TranscriptionReply(text string, duration float64) GroqReply {
+	return GroqReply{Response: t.GroqTranscriptionResponse{Text: text, Duration: duration}}
+}
+
+// GroqErrorReply returns a reply failing with err.
+func GroqErrorReply(err error) GroqReply {
+	return GroqReply{Err: err}
+}
+
+// GroqStatusReply returns a reply failing with the error the real client returns
+// for an HTTP error status, e.g. 400.
+func GroqStatusReply(statusCode int, body string) GroqReply {
+	return GroqErrorReply(&t.APIError{API: "Groq", StatusCode: statusCode, Body: body, Attempts: 1})
+}
+
+// FakeGroqClient is an in-memory clients.GroqClient. It is safe for concurrent use.
+// Chat completions are scripted separately with OpenRouterReply values, see EnqueueChat.
+type FakeGroqClient struct {
+	script script[GroqCall, GroqReply]
+	chat   script[OpenRouterCall, OpenRouterReply]
+}
+
+func NewFakeGroqClient() *FakeGroqClient {
+	return &FakeGroqClient{}
+}
+
+// Enqueue adds replies used in order by calls that match no rule.
+func (f *FakeGroqClient) Enqueue(replies ...GroqReply) *FakeGroqClient {
+	f.script.enqueue(replies...)
+	return f
+}
+
+// On adds a rule answering the calls for which match returns true.
+// Rules are checked in the order they were added, before the queue.
+func (f *FakeGroqClient) On(match func(GroqCall) bool) *Rule[GroqCall, GroqReply] {
+	return f.script.on(match)
+}
+
+// SetDefault sets the reply for calls that match no rule when the queue is empty.
+// Without a default such calls fail with ErrNoReply.
+func (f *FakeGroqClient) SetDefault(reply GroqReply) *FakeGroqClient {
+	f.script.setDefault(reply)
+	return f
+}
+
+// Calls returns all calls received so far.
+func (f *FakeGroqClient) Calls() []GroqCall {
+	return f.script.recorded()
+}
+
+// EnqueueChat adds chat completion replies used in order by chat calls that match no rule.
+func (f *FakeGroqClient) EnqueueChat(replies ...OpenRouterReply) *FakeGroqClient {
+	f.chat.enqueue(replies...)
+	return f
+}
+
+// OnChat adds a rule answering the chat calls for which match returns true, e.g. ForModel.
+func (f *FakeGroqClient) OnChat(match func(OpenRouterCall) bool) *Rule[OpenRouterCall, OpenRouterReply] {
+	return f.chat.on(match)
+}
+
+// SetChatDefault sets the reply for chat calls that match no rule when the chat queue is empty.
+func (f *FakeGroqClient) SetChatDefault(reply OpenRouterReply) *FakeGroqClient {
+	f.chat.setDefault(reply)
+	return f
+}
+
+// ChatCalls returns all chat calls received so far.
+func (f *FakeGroqClient) ChatCalls() []OpenRouterCall {
+	return f.chat.recorded()
+}
+
+// Reset removes all rules, queued replies, defaults and recorded calls.
+func (f *FakeGroqClient) Reset() {
+	f.script.reset()
+	f.chat.reset()
+}
+
+func (f *FakeGroqClient) GenerateText(
+	messages []t.MessageForLLM,
+	messageParts []t.PartMessageForLLM,
+	model string,
+	temperature *float64,
+	maxTokens *int,
+	timeOut *int,
+) (t.OpenRouterResponse, error) {
+	return chatReply(&f.chat, OpenRouterCall{
+		Method:       "GenerateText",
+		Messages:     messages,
+		MessageParts: messageParts,
+		Model:        model,
+		Temperature:  temperature,
+		MaxTokens:    maxTokens,
+		TimeOut:      timeOut,
+	})
+}
+
+func (f *FakeGroqClient) GenerateTools(
+	messages []t.MessageForLLM,
+	messageParts []t.PartMessageForLLM,
+	tools []t.ToolSchema,
+	model string,
+	temperature *float64,
+	maxTokens *int,
+	timeOut *int,
+) (t.OpenRouterResponse, error) {
+	return chatReply(&f.chat, OpenRouterCall{
+		Method:       "GenerateTools",
+		Messages:     messages,
+		MessageParts: messageParts,
+		Tools:        tools,
+		Model:        model,
+		Temperature:  temperature,
+		MaxTokens:    maxTokens,
+		TimeOut:      timeOut,
+	})
+}
+
+func (f *FakeGroqClient) GenerateStructured(
+	messages []t.MessageForLLM,
+	messageParts []t.PartMessageForLLM,
+	schema t.StructuredOutputSchema,
+	model string,
+	temperature *float64,
+	maxTokens *int,
+	timeOut *int,
+) (t.OpenRouterResponse, error) {
+	return chatReply(&f.chat, OpenRouterCall{
+		Method:       "GenerateStructured",
+		Messages:     messages,
+		MessageParts: messageParts,
+		Schema:       &schema,
+		Model:        model,
+		Temperature:  temperature,
+		MaxTokens:    maxTokens,
+		TimeOut:      timeOut,
+	})
+}
+
+func (f *FakeGroqClient) Transcribe(
+	model string,
+	language string,
+	audioURL *string,
+	audioBytes *[]byte,
+	timeOut *int,
+) (t.GroqTranscriptionResponse, error) {
+	return f.reply(GroqCall{
+		Method:     "Transcribe",
+		Model:      model,
+		Language:   language,
+		AudioURL:   audioURL,
+		AudioBytes: audioBytes,
+		TimeOut:    timeOut,
+	})
+}
+
+func (f *FakeGroqClient) TranscribeWithOptions(
+	model string,
+	language string,
+	audioURL *string,
+	audioBytes *[]byte,
+	timeOut *int,
+	options *t.TranscriptionOptions,
+) (t.GroqTranscriptionResponse, error) {
+	return f.reply(GroqCall{
+		Method:     "TranscribeWithOptions",
+		Model:      model,
+		Language:   language,
+		AudioURL:   audioURL,
+		AudioBytes: audioBytes,
+		TimeOut:    timeOut,
+		Options:    options,
+	})
+}
+
+func (f *FakeGroqClient) TranscribeReader(
+	model string,
+	language string,
+	audio io.Reader,
+	timeOut *int,
+	options *t.TranscriptionOptions,
+) (t.GroqTranscriptionResponse, error) {
+	return f.reply(GroqCall{
+		Method:   "TranscribeReader",
+		Model:    model,
+		Language: language,
+		Audio:    audio,
+		TimeOut:  timeOut,
+		Options:  options,
+	})
+}
+
+func (f *FakeGroqClient) TranscribeFile(
+	model string,
+	language string,
+	path string,
+	timeOut *int,
+	options *t.TranscriptionOptions,
+) (t.GroqTranscriptionResponse, error) {
+	return f.reply(GroqCall{
+		Method:    "TranscribeFile",
+		Model:     model,
+		Language:  language,
+		AudioPath: path,
+		TimeOut:   timeOut,
+		Options:   options,
+	})
+}
+
+func (f *FakeGroqClient) Translate(
+	model string,
+	audioURL *string,
+	audioBytes *[]byte,
+	timeOut *int,
+) (t.GroqTranscriptionResponse, error) {
+	return f.reply(GroqCall{
+		Method:     "Translate",
+		Model:      model,
+		AudioURL:   audioURL,
+		AudioBytes: audioBytes,
+		TimeOut:    timeOut,
+	})
+}
+
+func (f *FakeGroqClient) TranslateWithOptions(
+	model string,
+	audioURL *string,
+	audioBytes *[]byte,
+	timeOut *int,
+	options *t.TranscriptionOptions,
+) (t.GroqTranscriptionResponse, error) {
+	return f.reply(GroqCall{
+		Method:     "TranslateWithOptions",
+		Model:      model,
+		AudioURL:   audioURL,
+		AudioBytes: audioBytes,
+		TimeOut:    timeOut,
+		Options:    options,
+	})
+}
+
+func (f *FakeGroqClient) TranslateReader(
+	model string,
+	audio io.Reader,
+	timeOut *int,
+	options *t.TranscriptionOptions,
+) (t.GroqTranscriptionResponse, error) {
+	return f.reply(GroqCall{
+		Method:  "TranslateReader",
+		Model:   model,
+		Audio:   audio,
+		TimeOut: timeOut,
+		Options: options,
+	})
+}
+
+func (f *FakeGroqClient) TranslateFile(
+	model string,
+	path string,
+	timeOut *int,
+	options *t.TranscriptionOptions,
+) (t.GroqTranscriptionResponse, error) {
+	return f.reply(GroqCall{
+		Method:    "TranslateFile",
+		Model:     model,
+		AudioPath: path,
+		TimeOut:   timeOut,
+		Options:   options,
+	})
+}
+
+func (f *FakeGroqClient) reply(call GroqCall) (t.GroqTranscriptionResponse, error) {
+	reply, ok := f.script.next(call)
+	if !ok {
+		return t.GroqTranscriptionResponse{}, fmt.Errorf("%w: %s with model %q", ErrNoReply, call.Method, call.Model)
+	}
+	sleep(reply.Latency)
+	return reply.Response, reply.Err
+}
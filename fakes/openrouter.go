@@ -0,0 +1,254 @@
+package fakes
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Floris22/go-llm/v2/clients"
+	t "github.com/Floris22/go-llm/v2/llmtypes"
+)
+
+var _ clients.OpenRouterClient = (*FakeOpenRouterClient)(nil)
+
+// OpenRouterCall is a call received by a FakeOpenRouterClient.
+type OpenRouterCall struct {
+	// "GenerateText", "GenerateTools" or "GenerateStructured"
+	Method string
+
+	Messages     []t.MessageForLLM
+	MessageParts []t.PartMessageForLLM
+	Tools        []t.ToolSchema
+	Schema       *t.StructuredOutputSchema
+	Model        string
+	Temperature  *float64
+	MaxTokens    *int
+	TimeOut      *int
+	Reasoning    *t.ReasoningConfig
+	Provider     *t.ProviderConfig
+	Plugins      []t.Plugin
+}
+
+// Text returns the text of all messages of the call, joined by newlines.
+func (c OpenRouterCall) Text() string {
+	var texts []string
+	for _, message := range c.Messages {
+		if message.Content != nil {
+			texts = append(texts, *message.Content)
+		}
+	}
+	for _, message := range c.MessageParts {
+		for _, part := range message.Content {
+			if part.Text != "" {
+				texts = append(texts, part.Text)
+			}
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// OpenRouterReply is a scripted reply of a FakeOpenRouterClient.
+type OpenRouterReply struct {
+	Response t.OpenRouterResponse
+	Err      error
+
+	// Simulated time before the reply is returned
+	Latency time.Duration
+}
+
+// TextReply returns a reply with a single assistant message.
+func TextReply(content string) OpenRouterReply {
+	var response t.OpenRouterResponse
+	response.Choices = make([]t.OpenRouterChoice, 1)
+	response.Choices[0].FinishReason = "stop"
+	response.Choices[0].Message.Role = t.RoleAssistant
+	response.Choices[0].Message.Content = content
+	return OpenRouterReply{Response: response}
+}
+
+// ToolCallReply returns a reply with a single tool call, arguments is the JSON encoded arguments.
+func ToolCallReply(name string, arguments string) OpenRouterReply {
+	reply := TextReply("")
+	reply.Response.Choices[0].FinishReason = "tool_calls"
+	reply.Response.Choices[0].Message.ToolCalls = []t.MessageForLLMToolCalls{{
+		ID:   "call_" + name,
+		Type: "function",
+		Function: t.MessageForLLMToolCallsFunction{
+			Name:      name,
+			Arguments: arguments,
+		},
+	}}
+	return reply
+}
+
+// ErrorReply returns a reply failing with err.
+func ErrorReply(err error) OpenRouterReply {
+	return OpenRouterReply{Err: err}
+}
+
+// StatusReply returns a reply failing with the error the real client returns
+// for an HTTP error status, e.g. 429.
+func StatusReply(statusCode int, body string) OpenRouterReply {
+	return ErrorReply(&t.APIError{API: "OpenRouter", StatusCode: statusCode, Body: body, Attempts: 1})
+}
+
+// FakeOpenRouterClient is an in-memory clients.OpenRouterClient. It is safe for concurrent use.
+type FakeOpenRouterClient struct {
+	script script[OpenRouterCall, OpenRouterReply]
+}
+
+func NewFakeOpenRouterClient() *FakeOpenRouterClient {
+	return &FakeOpenRouterClient{}
+}
+
+// Enqueue adds replies used in order by calls that match no rule.
+func (f *FakeOpenRouterClient) Enqueue(replies ...OpenRouterReply) *FakeOpenRouterClient {
+	f.script.enqueue(replies...)
+	return f
+}
+
+// On adds a rule answering the calls for which match returns true.
+// Rules are checked in the order they were added, before the queue.
+func (f *FakeOpenRouterClient) On(match func(OpenRouterCall) bool) *Rule[OpenRouterCall, OpenRouterReply] {
+	return f.script.on(match)
+}
+
+// SetDefault sets the reply for calls that match no rule when the queue is empty.
+// Without a default such calls fail with ErrNoReply.
+func (f *FakeOpenRouterClient) SetDefault(reply OpenRouterReply) *FakeOpenRouterClient {
+	f.script.setDefault(reply)
+	return f
+}
+
+// Calls returns all calls received so far.
+func (f *FakeOpenRouterClient) Calls() []OpenRouterCall {
+	return f.script.recorded()
+}
+
+// Reset removes all rules, queued replies, the default and recorded calls.
+func (f *FakeOpenRouterClient) Reset() {
+	f.script.reset()
+}
+
+func (f *FakeOpenRouterClient) GenerateText(
+	messages []t.MessageForLLM,
+	messageParts []t.PartMessageForLLM,
+	model string,
+	temperature *float64,
+	maxTokens *int,
+	timeOut *int,
+	reasoning *t.ReasoningConfig,
+	provider *t.ProviderConfig,
+	plugins []t.Plugin,
+) (t.OpenRouterResponse, error) {
+	return f.reply(OpenRouterCall{
+		Method:       "GenerateText",
+		Messages:     messages,
+		MessageParts: messageParts,
+		Model:        model,
+		Temperature:  temperature,
+		MaxTokens:    maxTokens,
+		TimeOut:      timeOut,
+		Reasoning:    reasoning,
+		Provider:     provider,
+		Plugins:      plugins,
+	})
+}
+
+func (f *FakeOpenRouterClient) GenerateTools(
+	messages []t.MessageForLLM,
+	messageParts []t.PartMessageForLLM,
+	tools []t.ToolSchema,
+	model string,
+	temperature *float64,
+	maxTokens *int,
+	timeOut *int,
+	reasoning *t.ReasoningConfig,
+	provider *t.ProviderConfig,
+	plugins []t.Plugin,
+) (t.OpenRouterResponse, error) {
+	return f.reply(OpenRouterCall{
+		Method:       "GenerateTools",
+		Messages:     messages,
+		MessageParts: messageParts,
+		Tools:        tools,
+		Model:        model,
+		Temperature:  temperature,
+		MaxTokens:    maxTokens,
+		TimeOut:      timeOut,
+		Reasoning:    reasoning,
+		Provider:     provider,
+		Plugins:      plugins,
+	})
+}
+
+func (f *FakeOpenRouterClient) GenerateStructured(
+	messages []t.MessageForLLM,
+	messageParts []t.PartMessageForLLM,
+	schema t.StructuredOutputSchema,
+	model string,
+	temperature *float64,
+	maxTokens *int,
+	timeOut *int,
+	reasoning *t.ReasoningConfig,
+	provider *t.ProviderConfig,
+	plugins []t.Plugin,
+) (t.OpenRouterResponse, error) {
+	return f.reply(OpenRouterCall{
+		Method:       "GenerateStructured",
+		Messages:     messages,
+		MessageParts: messageParts,
+		Schema:       &schema,
+		Model:        model,
+		Temperature:  temperature,
+		MaxTokens:    maxTokens,
+		TimeOut:      timeOut,
+		Reasoning:    reasoning,
+		Provider:     provider,
+		Plugins:      plugins,
+	})
+}
+
+func (f *FakeOpenRouterClient) reply(call OpenRouterCall) (t.OpenRouterResponse, error) {
+	return chatReply(&f.script, call)
+}
+
+// chatReply returns the scripted reply to a chat completion call.
+func chatReply(script *script[OpenRouterCall, OpenRouterReply], call OpenRouterCall) (t.OpenRouterResponse, error) {
+	reply, ok := script.next(call)
+	if !ok {
+		return t.OpenRouterResponse{}, fmt.Errorf("%w: %s with model %q", ErrNoReply, call.Method, call.Model)
+	}
+	sleep(reply.Latency)
+	if reply.Response.Model == "" && reply.Err == nil {
+		reply.Response.Model = call.Model
+	}
+	return reply.Response, reply.Err
+}
+
+// ForModel matches calls for model.
+func ForModel(model string) func(OpenRouterCall) bool {
+	return func(call OpenRouterCall) bool { return call.Model == model }
+}
+
+// ForMethod matches calls of method, e.g. "GenerateTools".
+func ForMethod(method string) func(OpenRouterCall) bool {
+	return func(call OpenRouterCall) bool { return call.Method == method }
+}
+
+// MessagesContain matches calls whose message text contains substr.
+func MessagesContain(substr string) func(OpenRouterCall) bool {
+	return func(call OpenRouterCall) bool { return strings.Contains(call.Text(), substr) }
+}
+
+// All matches calls matched by all predicates.
+func All[C any](predicates ...func(C) bool) func(C) bool {
+	return func(call C) bool {
+		for _, predicate := range predicates {
+			if !predicate(call) {
+				return false
+			}
+		}
+		return true
+	}
+}
@@ -0,0 +1,121 @@
+// Package fakes provides in-memory implementations of the client interfaces for unit tests.
+// Replies are scripted with Enqueue and On, and every call is recorded for assertions.
+package fakes
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNoReply is returned when a call matches no rule and the reply queue is empty.
+var ErrNoReply = errors.New("fakes: no reply scripted for call")
+
+// script holds the scripted replies and recorded calls of a fake client.
+type script[C any, R any] struct {
+	mu       sync.Mutex
+	queue    []R
+	rules    []*Rule[C, R]
+	fallback *R
+	calls    []C
+}
+
+// Rule answers the calls matching its predicate, see On.
+type Rule[C any, R any] struct {
+	match   func(C) bool
+	replies []R
+	always  *R
+	script  *script[C, R]
+}
+
+// Return queues replies for the matching calls, used once each in order.
+// Once they are used up, matching calls fall through to the next rule.
+func (r *Rule[C, R]) Return(replies ...R) *Rule[C, R] {
+	r.script.mu.Lock()
+	defer r.script.mu.Unlock()
+	r.replies = append(r.replies, replies...)
+	return r
+}
+
+// Always answers every matching call with reply once the queued replies are used up.
+func (r *Rule[C, R]) Always(reply R) *Rule[C, R] {
+	r.script.mu.Lock()
+	defer r.script.mu.Unlock()
+	r.always = &reply
+	return r
+}
+
+func (s *script[C, R]) on(match func(C) bool) *Rule[C, R] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rule := &Rule[C, R]{match: match, script: s}
+	s.rules = append(s.rules, rule)
+	return rule
+}
+
+func (s *script[C, R]) enqueue(replies ...R) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = append(s.queue, replies...)
+}
+
+func (s *script[C, R]) setDefault(reply R) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fallback = &reply
+}
+
+// next records call and returns its reply: the first matching rule with a reply left,
+// then the queue, then the default.
+func (s *script[C, R]) next(call C) (R, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, call)
+
+	for _, rule := range s.rules {
+		if !rule.match(call) {
+			continue
+		}
+		if len(rule.replies) > 0 {
+			reply := rule.replies[0]
+			rule.replies = rule.replies[1:]
+			return reply, true
+		}
+		if rule.always != nil {
+			return *rule.always, true
+		}
+	}
+	if len(s.queue) > 0 {
+		reply := s.queue[0]
+		s.queue = s.queue[1:]
+		return reply, true
+	}
+	if s.fallback != nil {
+		return *s.fallback, true
+	}
+	var zero R
+	return zero, false
+}
+
+func (s *script[C, R]) recorded() []C {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	calls := make([]C, len(s.calls))
+	copy(calls, s.calls)
+	return calls
+}
+
+func (s *script[C, R]) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = nil
+	s.rules = nil
+	s.fallback = nil
+	s.calls = nil
+}
+
+func sleep(latency time.Duration) {
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+}
@@ -0,0 +1,37 @@
+package helpers
+
+import "bytes"
+
+type AudioFormatEnum string
+
+const (
+	AudioFormatWAV     AudioFormatEnum = "wav"
+	AudioFormatMP3     AudioFormatEnum = "mp3"
+	AudioFormatFLAC    AudioFormatEnum = "flac"
+	AudioFormatOgg     AudioFormatEnum = "ogg"
+	AudioFormatMP4     AudioFormatEnum = "m4a"
+	AudioFormatWebM    AudioFormatEnum = "webm"
+	AudioFormatUnknown AudioFormatEnum = "unknown"
+)
+
+// DetectAudioFormat detects the container format from the first bytes of an audio file.
+func DetectAudioFormat(header []byte) AudioFormatEnum {
+	switch {
+	case len(header) >= 12 && bytes.Equal(header[0:4], []byte("RIFF")) && bytes.Equal(header[8:12], []byte("WAVE")):
+		return AudioFormatWAV
+	case bytes.HasPrefix(header, []byte("fLaC")):
+		return AudioFormatFLAC
+	case bytes.HasPrefix(header, []byte("OggS")):
+		return AudioFormatOgg
+	case bytes.HasPrefix(header, []byte("\x1a\x45\xdf\xa3")):
+		return AudioFormatWebM
+	case len(header) >= 8 && bytes.Equal(header[4:8], []byte("ftyp")):
+		return AudioFormatMP4
+	// MPEG audio frames start with 11 sync bits followed by the version and a non-zero layer,
+	// AAC ADTS frames have the same sync bits but layer 0
+	case bytes.HasPrefix(header, []byte("ID3")),
+		len(header) >= 2 && header[0] == 0xFF && header[1]&0xE0 == 0xE0 && header[1]&0x06 != 0:
+		return AudioFormatMP3
+	}
+	return AudioFormatUnknown
+}
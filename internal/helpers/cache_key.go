@@ -0,0 +1,22 @@
+package helpers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json/v2"
+)
+
+// CacheKey returns a hash of a request body created by CreateRequestBody.
+// The body is canonicalised first, so the key doesn't depend on key order or whitespace.
+func CacheKey(body []byte) (string, error) {
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return "", err
+	}
+	canonical, err := json.Marshal(value, json.Deterministic(true))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
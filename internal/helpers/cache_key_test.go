@@ -0,0 +1,59 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/Floris22/go-llm/v2/llmtypes"
+)
+
+func TestCacheKey(t *testing.T) {
+	content := "hello"
+	messages := []llmtypes.MessageForLLM{{Role: llmtypes.RoleUser, Content: &content}}
+	temperature := 0.2
+	tools := []llmtypes.ToolSchema{{Name: "lookup", Description: "Look something up"}}
+	provider := &llmtypes.ProviderConfig{Sort: llmtypes.SortBy(llmtypes.SortPrice)}
+
+	key := func(t *testing.T, temperature *float64, tools *[]llmtypes.ToolSchema, provider *llmtypes.ProviderConfig) string {
+		t.Helper()
+		body, err := CreateRequestBody(messages, nil, "model", temperature, nil, nil, tools, nil, provider, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := CacheKey(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	base := key(t, nil, nil, nil)
+
+	if again := key(t, nil, nil, nil); again != base {
+		t.Errorf("same request gave keys %s and %s", base, again)
+	}
+	reordered, err := CacheKey([]byte(`{"temperature": 0.7, "model": "model",
+		"messages": [{"content": "hello", "role": "user"}], "max_tokens": 32000}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reordered != base {
+		t.Error("key depends on key order or whitespace")
+	}
+
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{name: "temperature", key: key(t, &temperature, nil, nil)},
+		{name: "tools", key: key(t, nil, &tools, nil)},
+		{name: "provider", key: key(t, nil, nil, provider)},
+	}
+	for _, test := range tests {
+		if test.key == base {
+			t.Errorf("different %s gave the same key", test.name)
+		}
+	}
+
+	if _, err := CacheKey([]byte("not json")); err == nil {
+		t.Error("expected an error for a body that isn't JSON")
+	}
+}
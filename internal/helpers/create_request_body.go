@@ -3,7 +3,6 @@ package helpers
 import (
 	"encoding/json/v2"
 	"fmt"
-	"log"
 
 	t "github.com/Floris22/go-llm/v2/llmtypes"
 )
@@ -18,7 +17,48 @@ func CreateRequestBody(
 	tools *[]t.ToolSchema,
 	reasoning *t.ReasoningConfig,
 	provider *t.ProviderConfig,
+	plugins []t.Plugin,
 ) ([]byte, error) {
+	reqBody, err := requestBody(messages, messageParts, model, temperature, maxTokens, schema, tools, reasoning, provider, plugins)
+	if err != nil {
+		return nil, err
+	}
+	return marshalRequestBody(reqBody)
+}
+
+// CreateGroqRequestBody creates the body of a Groq chat completion. Without maxTokens the field
+// is left out, so each model uses its own limit, which for many is below the default of CreateRequestBody.
+func CreateGroqRequestBody(
+	messages []t.MessageForLLM,
+	messageParts []t.PartMessageForLLM,
+	model string,
+	temperature *float64,
+	maxTokens *int,
+	schema *t.StructuredOutputSchema,
+	tools *[]t.ToolSchema,
+) ([]byte, error) {
+	reqBody, err := requestBody(messages, messageParts, model, temperature, maxTokens, schema, tools, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if maxTokens == nil {
+		delete(reqBody, "max_tokens")
+	}
+	return marshalRequestBody(reqBody)
+}
+
+func requestBody(
+	messages []t.MessageForLLM,
+	messageParts []t.PartMessageForLLM,
+	model string,
+	temperature *float64,
+	maxTokens *int,
+	schema *t.StructuredOutputSchema,
+	tools *[]t.ToolSchema,
+	reasoning *t.ReasoningConfig,
+	provider *t.ProviderConfig,
+	plugins []t.Plugin,
+) (map[string]any, error) {
 	maxTokensValue := 32000
 	temperatureValue := 0.7
 
@@ -98,9 +138,16 @@ func CreateRequestBody(
 		reqBody["provider"] = provider
 	}
 
+	if len(plugins) > 0 {
+		reqBody["plugins"] = plugins
+	}
+	return reqBody, nil
+}
+
+func marshalRequestBody(reqBody map[string]any) ([]byte, error) {
 	body, err := json.Marshal(reqBody)
 	if err != nil {
-		log.Fatalf("Error marshaling JSON: %v", err)
+		return nil, fmt.Errorf("Error marshaling JSON: %w", err)
 	}
 	return body, nil
 }
@@ -2,50 +2,74 @@ package helpers
 
 import (
 	"context"
+	"errors"
 	"math"
 	"time"
+
+	"github.com/Floris22/go-llm/v2/circuitbreaker"
+	t "github.com/Floris22/go-llm/v2/llmtypes"
+	"github.com/Floris22/go-llm/v2/ratelimit"
 )
 
+// SendFunc sends a single request attempt.
+type SendFunc func(ctx context.Context) (Response, error)
+
 func DoGroqWithRetries(
 	ctx context.Context,
-	headers map[string]string,
-	body []byte,
+	send SendFunc,
 ) ([]byte, error) {
-	var lastErr error
-	for attempt := range 5 {
-		respBody, statusCode, err := PostReq(
-			ctx, "https://api.groq.com/openai/v1/audio/transcriptions", headers, body, nil,
-		)
-		if err == nil && statusCode == 200 {
-			return respBody, nil
-		}
-		lastErr = err
-
-		if attempt+1 < 5 {
-			time.Sleep(time.Duration(100*math.Pow(2, float64(attempt))) * time.Millisecond)
-		}
-	}
-	return nil, lastErr
+	return doWithRetries(ctx, 5, send)
 }
 
 func DoReqWithRetries(
 	ctx context.Context,
-	headers map[string]string,
-	body []byte,
+	send SendFunc,
+) ([]byte, error) {
+	return doWithRetries(ctx, 3, send)
+}
+
+// doWithRetries calls send until it returns status 200, with exponential backoff.
+// On failure it returns the body of the last response and its error.
+// Fail fast rate limit and open circuit errors are returned immediately.
+func doWithRetries(
+	ctx context.Context,
+	attempts int,
+	send SendFunc,
 ) ([]byte, error) {
 	var lastErr error
-	for attempt := range 3 {
-		respBody, statusCode, err := PostReq(
-			ctx, "https://openrouter.ai/api/v1/chat/completions", headers, body, nil,
-		)
-		if err == nil && statusCode == 200 {
-			return respBody, nil
+	var lastBody []byte
+	for attempt := range attempts {
+		resp, err := send(ctx)
+		if err == nil && resp.StatusCode == 200 {
+			return resp.Body, nil
+		}
+		if errors.Is(err, ratelimit.ErrRateLimited) || errors.Is(err, circuitbreaker.ErrOpen) {
+			return resp.Body, err
+		}
+		if err == nil {
+			err = &t.APIError{StatusCode: resp.StatusCode, Body: string(resp.Body), Attempts: 1}
 		}
 		lastErr = err
+		lastBody = resp.Body
 
-		if attempt+1 < 3 {
-			time.Sleep(time.Duration(100*math.Pow(2, float64(attempt))) * time.Millisecond)
+		if attempt+1 < attempts {
+			select {
+			case <-time.After(time.Duration(100*math.Pow(2, float64(attempt))) * time.Millisecond):
+			case <-ctx.Done():
+				return lastBody, TimeoutCause(ctx, ctx.Err())
+			}
 		}
 	}
-	return nil, lastErr
+	return lastBody, lastErr
+}
+
+// RetriesFailedError returns the error of a request that still failed after attempts,
+// err and body are the result of the last attempt as returned by DoReqWithRetries.
+func RetriesFailedError(api string, attempts int, err error, body []byte) *t.APIError {
+	retriesErr := &t.APIError{API: api, Body: string(body), Attempts: attempts}
+	var apiErr *t.APIError
+	if errors.As(err, &apiErr) {
+		retriesErr.StatusCode = apiErr.StatusCode
+	}
+	return retriesErr
 }
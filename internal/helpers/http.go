@@ -3,10 +3,133 @@ package helpers
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io"
 	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	t "github.com/Floris22/go-llm/v2/llmtypes"
 )
 
+// Response is the result of a request made with PostReqWithDeadlines.
+type Response struct {
+	Body       []byte
+	StatusCode int
+	Header     http.Header
+}
+
+// Deadlines limits the phases of a single request, zero means no limit.
+// The total deadline is set on the context, see WithTotalTimeout.
+type Deadlines struct {
+	// Time to establish a connection, including DNS and TLS
+	Connect time.Duration
+
+	// Time from sending the request until the first response byte
+	FirstByte time.Duration
+}
+
+// WithTotalTimeout returns a context that is cancelled with a *t.TimeoutError after timeout.
+func WithTotalTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeoutCause(ctx, timeout, &t.TimeoutError{Phase: t.TimeoutPhaseTotal, Timeout: timeout})
+}
+
+// TimeoutCause returns the *t.TimeoutError that cancelled ctx, or err if ctx wasn't cancelled by a deadline.
+func TimeoutCause(ctx context.Context, err error) error {
+	var timeoutErr *t.TimeoutError
+	if errors.As(context.Cause(ctx), &timeoutErr) {
+		return timeoutErr
+	}
+	return err
+}
+
+// PostReqWithDeadlines works like PostReq but enforces the connect and first byte deadlines,
+// and also returns the response headers.
+// If a deadline expires, the returned error is a *t.TimeoutError for that phase.
+func PostReqWithDeadlines(
+	ctx context.Context,
+	url string,
+	headers map[string]string,
+	body []byte,
+	client *http.Client,
+	deadlines Deadlines,
+) (Response, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	timeout := func(phase t.TimeoutPhaseEnum, timeout time.Duration) func() {
+		return func() { cancel(&t.TimeoutError{Phase: phase, Timeout: timeout}) }
+	}
+
+	// The trace hooks may run on other goroutines
+	var mu sync.Mutex
+	var connectTimer, firstByteTimer *time.Timer
+	gotFirstByte := false
+	stopConnect := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if connectTimer != nil {
+			connectTimer.Stop()
+		}
+	}
+	startFirstByte := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if deadlines.FirstByte > 0 && firstByteTimer == nil && !gotFirstByte {
+			firstByteTimer = time.AfterFunc(deadlines.FirstByte, timeout(t.TimeoutPhaseFirstToken, deadlines.FirstByte))
+		}
+	}
+	stopFirstByte := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		gotFirstByte = true
+		if firstByteTimer != nil {
+			firstByteTimer.Stop()
+		}
+	}
+	if deadlines.Connect > 0 {
+		connectTimer = time.AfterFunc(deadlines.Connect, timeout(t.TimeoutPhaseConnect, deadlines.Connect))
+	}
+	defer stopConnect()
+	defer stopFirstByte()
+
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn:              func(httptrace.GotConnInfo) { stopConnect() },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { startFirstByte() },
+		GotFirstResponseByte: stopFirstByte,
+	})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return Response{}, err
+	}
+
+	if headers["Content-Type"] == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Response{}, TimeoutCause(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Response{StatusCode: resp.StatusCode, Header: resp.Header}, TimeoutCause(ctx, err)
+	}
+
+	return Response{Body: respBody, StatusCode: resp.StatusCode, Header: resp.Header}, nil
+}
+
 // PostReq requires a context and url, other parameters are optional.
 // Returns the response body as bytes, the HTTP status code and any error.
 // HTTP status code 0 means an error / cancellation occured before any request was sent.
@@ -0,0 +1,56 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Floris22/go-llm/v2/llmtypes"
+)
+
+func TestPostReqWithDeadlinesFirstByte(t *testing.T) {
+	tests := []struct {
+		name         string
+		dialDelay    time.Duration
+		handlerDelay time.Duration
+		wantPhase    llmtypes.TimeoutPhaseEnum
+	}{
+		{name: "slow connect is not first byte time", dialDelay: 150 * time.Millisecond},
+		{name: "slow response", handlerDelay: 150 * time.Millisecond, wantPhase: llmtypes.TimeoutPhaseFirstToken},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(test.handlerDelay)
+				w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			dialer := &net.Dialer{}
+			client := &http.Client{Transport: &http.Transport{
+				DialContext: func(ctx context.Context, network string, addr string) (net.Conn, error) {
+					time.Sleep(test.dialDelay)
+					return dialer.DialContext(ctx, network, addr)
+				},
+			}}
+
+			_, err := PostReqWithDeadlines(context.Background(), server.URL, nil, []byte(`{}`), client, Deadlines{
+				FirstByte: 50 * time.Millisecond,
+			})
+			var timeoutErr *llmtypes.TimeoutError
+			switch {
+			case test.wantPhase == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case test.wantPhase != "" && !errors.As(err, &timeoutErr):
+				t.Fatalf("error = %v, want a *TimeoutError", err)
+			case test.wantPhase != "" && timeoutErr.Phase != test.wantPhase:
+				t.Fatalf("phase = %s, want %s", timeoutErr.Phase, test.wantPhase)
+			}
+		})
+	}
+}
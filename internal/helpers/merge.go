@@ -0,0 +1,105 @@
+package helpers
+
+import (
+	"maps"
+
+	t "github.com/Floris22/go-llm/v2/llmtypes"
+)
+
+// FirstNonNil returns the first non-nil pointer, or nil if all are nil.
+func FirstNonNil[T any](values ...*T) *T {
+	for _, v := range values {
+		if v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// MergeReasoningConfig returns base with every non-nil field of override applied.
+// Returns nil if both are nil.
+func MergeReasoningConfig(base, override *t.ReasoningConfig) *t.ReasoningConfig {
+	if base == nil || override == nil {
+		return FirstNonNil(override, base)
+	}
+	merged := *base
+	merged.Effort = FirstNonNil(override.Effort, base.Effort)
+	merged.MaxTokens = FirstNonNil(override.MaxTokens, base.MaxTokens)
+	merged.Enabled = FirstNonNil(override.Enabled, base.Enabled)
+	return &merged
+}
+
+// MergeProviderConfig returns base with every non-nil field of override applied.
+// Returns nil if both are nil.
+func MergeProviderConfig(base, override *t.ProviderConfig) *t.ProviderConfig {
+	if base == nil || override == nil {
+		return FirstNonNil(override, base)
+	}
+	merged := *base
+	merged.Order = FirstNonNil(override.Order, base.Order)
+	merged.AllowFallbacks = FirstNonNil(override.AllowFallbacks, base.AllowFallbacks)
+	merged.RequireParameters = FirstNonNil(override.RequireParameters, base.RequireParameters)
+	merged.DataCollection = FirstNonNil(override.DataCollection, base.DataCollection)
+	merged.ZDR = FirstNonNil(override.ZDR, base.ZDR)
+	merged.EnforceDistillableText = FirstNonNil(override.EnforceDistillableText, base.EnforceDistillableText)
+	merged.Only = FirstNonNil(override.Only, base.Only)
+	merged.Ignore = FirstNonNil(override.Ignore, base.Ignore)
+	merged.Quantizations = FirstNonNil(override.Quantizations, base.Quantizations)
+	merged.Sort = FirstNonNil(override.Sort, base.Sort)
+	merged.MaxPrice = FirstNonNil(override.MaxPrice, base.MaxPrice)
+	return &merged
+}
+
+// MergePlugins returns base with the plugins of override appended.
+// A plugin in override replaces the plugin with the same ID in base.
+func MergePlugins(base, override []t.Plugin) []t.Plugin {
+	if len(base) == 0 || len(override) == 0 {
+		if len(override) > 0 {
+			return override
+		}
+		return base
+	}
+
+	merged := make([]t.Plugin, 0, len(base)+len(override))
+	for _, plugin := range base {
+		replaced := false
+		for _, o := range override {
+			if o.ID == plugin.ID {
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, plugin)
+		}
+	}
+	return append(merged, override...)
+}
+
+// MergeHeaders returns a new map with the headers of override applied on top of base.
+func MergeHeaders(base, override map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(override))
+	maps.Copy(merged, base)
+	maps.Copy(merged, override)
+	return merged
+}
+
+// IgnoreProviders returns a copy of provider with providers added to its Ignore list.
+// Returns provider unchanged if providers is empty.
+func IgnoreProviders(provider *t.ProviderConfig, providers []string) *t.ProviderConfig {
+	if len(providers) == 0 {
+		return provider
+	}
+
+	var merged t.ProviderConfig
+	if provider != nil {
+		merged = *provider
+	}
+	var ignore []string
+	if merged.Ignore != nil {
+		ignore = append(ignore, *merged.Ignore...)
+	}
+	ignore = append(ignore, providers...)
+	merged.Ignore = &ignore
+	return &merged
+}
@@ -0,0 +1,210 @@
+package helpers
+
+import (
+	"strings"
+
+	t "github.com/Floris22/go-llm/v2/llmtypes"
+)
+
+// MergeTranscriptions joins the transcriptions of consecutive audio chunks into one.
+// offsets are the chunk starts in seconds, when nil each chunk starts where the previous one ended.
+// Segment and word timestamps are offset and segment IDs are renumbered, so the result has
+// a continuous timeline. Text transcribed twice where chunks overlap is removed, see dedupeOverlap.
+func MergeTranscriptions(chunks []t.GroqTranscriptionResponse, offsets []float64) t.GroqTranscriptionResponse {
+	var merged t.GroqTranscriptionResponse
+	var texts []string
+	lastSegments := 0
+	for i, chunk := range chunks {
+		offset := merged.Duration
+		if offsets != nil {
+			offset = offsets[i]
+		}
+		chunk = shiftTranscription(chunk, offset)
+		if merged.Language == "" {
+			merged.Language = chunk.Language
+		}
+
+		if i > 0 && offset < merged.Duration {
+			texts[len(texts)-1], chunk.Text = dedupeOverlap(&merged, &chunk, lastSegments, texts[len(texts)-1], offset)
+		}
+
+		texts = append(texts, chunk.Text)
+		lastSegments = len(merged.Segments)
+		for _, segment := range chunk.Segments {
+			segment.ID = len(merged.Segments)
+			merged.Segments = append(merged.Segments, segment)
+		}
+		merged.Words = append(merged.Words, chunk.Words...)
+		merged.Duration = max(merged.Duration, offset+chunk.Duration)
+	}
+	merged.Text = strings.Join(texts, "")
+	return merged
+}
+
+// shiftTranscription returns a copy of chunk with its timestamps moved by offset seconds.
+func shiftTranscription(chunk t.GroqTranscriptionResponse, offset float64) t.GroqTranscriptionResponse {
+	segments := make([]t.TranscriptionSegment, len(chunk.Segments))
+	for i, segment := range chunk.Segments {
+		segment.Start += offset
+		segment.End += offset
+		segments[i] = segment
+	}
+	words := make([]t.TranscriptionWord, len(chunk.Words))
+	for i, word := range chunk.Words {
+		word.Start += offset
+		word.End += offset
+		words[i] = word
+	}
+	chunk.Segments, chunk.Words = segments, words
+	return chunk
+}
+
+// dedupeOverlap removes what was transcribed twice in the overlap of the merged chunks and chunk,
+// which starts at offset. Both sides are cut at the same point: the word boundary closest
+// to the middle of the overlap, or the middle itself without word timestamps.
+// Words are kept on the side of the cut their midpoint falls on and the texts are trimmed
+// by the number of words removed, as is the segment spanning the cut on each side, which
+// ends or starts at the cut. Without word timestamps segments are kept by their midpoint,
+// clamped to the cut, and the texts are rebuilt from the kept segments.
+// It returns the trimmed text of the previous chunk, whose segments start at lastSegments, and of chunk.
+func dedupeOverlap(
+	merged *t.GroqTranscriptionResponse,
+	chunk *t.GroqTranscriptionResponse,
+	lastSegments int,
+	previousText string,
+	offset float64,
+) (string, string) {
+	cut := (offset + merged.Duration) / 2
+	hasWords := len(merged.Words) > 0 && len(chunk.Words) > 0
+	if hasWords {
+		best := -1.0
+		for _, word := range merged.Words {
+			if word.End >= offset && word.End <= merged.Duration && (best < 0 || abs(word.End-cut) < abs(best-cut)) {
+				best = word.End
+			}
+		}
+		if best >= 0 {
+			cut = best
+		}
+	}
+
+	kept := len(merged.Words)
+	for kept > 0 && midpoint(merged.Words[kept-1].Start, merged.Words[kept-1].End) >= cut {
+		kept--
+	}
+	droppedWords := merged.Words[kept:]
+	merged.Words = merged.Words[:kept]
+	skipped := 0
+	for skipped < len(chunk.Words) && midpoint(chunk.Words[skipped].Start, chunk.Words[skipped].End) < cut {
+		skipped++
+	}
+	skippedWords := chunk.Words[:skipped]
+	chunk.Words = chunk.Words[skipped:]
+
+	previousSegments := merged.Segments[lastSegments:]
+	if hasWords {
+		previousSegments = segmentsBefore(previousSegments, droppedWords, cut)
+		chunk.Segments = segmentsAfter(chunk.Segments, skippedWords, cut)
+		merged.Segments = merged.Segments[:lastSegments+len(previousSegments)]
+		return trimFields(previousText, 0, len(droppedWords)), trimFields(chunk.Text, len(skippedWords), 0)
+	}
+
+	for len(previousSegments) > 0 && midpoint(previousSegments[len(previousSegments)-1].Start, previousSegments[len(previousSegments)-1].End) >= cut {
+		previousSegments = previousSegments[:len(previousSegments)-1]
+	}
+	if len(previousSegments) > 0 {
+		previousSegments[len(previousSegments)-1].End = min(previousSegments[len(previousSegments)-1].End, cut)
+	}
+	merged.Segments = merged.Segments[:lastSegments+len(previousSegments)]
+	firstSegment := 0
+	for firstSegment < len(chunk.Segments) && midpoint(chunk.Segments[firstSegment].Start, chunk.Segments[firstSegment].End) < cut {
+		firstSegment++
+	}
+	chunk.Segments = chunk.Segments[firstSegment:]
+	if len(chunk.Segments) > 0 {
+		chunk.Segments[0].Start = max(chunk.Segments[0].Start, cut)
+	}
+	return segmentsText(previousSegments), segmentsText(chunk.Segments)
+}
+
+// segmentsBefore drops the segments starting after cut and ends the one spanning it at cut,
+// removing the dropped words it contains from its text.
+func segmentsBefore(segments []t.TranscriptionSegment, dropped []t.TranscriptionWord, cut float64) []t.TranscriptionSegment {
+	for len(segments) > 0 && segments[len(segments)-1].Start >= cut {
+		segments = segments[:len(segments)-1]
+	}
+	if len(segments) == 0 || segments[len(segments)-1].End <= cut {
+		return segments
+	}
+
+	last := &segments[len(segments)-1]
+	inside := 0
+	for _, word := range dropped {
+		if middle := midpoint(word.Start, word.End); middle >= last.Start && middle < last.End {
+			inside++
+		}
+	}
+	last.Text = trimFields(last.Text, 0, inside)
+	last.End = cut
+	if strings.TrimSpace(last.Text) == "" {
+		segments = segments[:len(segments)-1]
+	}
+	return segments
+}
+
+// segmentsAfter drops the segments ending before cut and starts the one spanning it at cut,
+// removing the skipped words it contains from its text.
+func segmentsAfter(segments []t.TranscriptionSegment, skipped []t.TranscriptionWord, cut float64) []t.TranscriptionSegment {
+	for len(segments) > 0 && segments[0].End <= cut {
+		segments = segments[1:]
+	}
+	if len(segments) == 0 || segments[0].Start >= cut {
+		return segments
+	}
+
+	first := &segments[0]
+	inside := 0
+	for _, word := range skipped {
+		if middle := midpoint(word.Start, word.End); middle >= first.Start && middle < first.End {
+			inside++
+		}
+	}
+	first.Text = trimFields(first.Text, inside, 0)
+	first.Start = cut
+	if strings.TrimSpace(first.Text) == "" {
+		segments = segments[1:]
+	}
+	return segments
+}
+
+// trimFields removes the first and last words of text, keeping its leading space.
+func trimFields(text string, first int, last int) string {
+	fields := strings.Fields(text)
+	if first+last >= len(fields) {
+		return ""
+	}
+	trimmed := strings.Join(fields[first:len(fields)-last], " ")
+	if strings.HasPrefix(text, " ") {
+		trimmed = " " + trimmed
+	}
+	return trimmed
+}
+
+func segmentsText(segments []t.TranscriptionSegment) string {
+	var b strings.Builder
+	for _, segment := range segments {
+		b.WriteString(segment.Text)
+	}
+	return b.String()
+}
+
+func midpoint(start float64, end float64) float64 {
+	return (start + end) / 2
+}
+
+func abs(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
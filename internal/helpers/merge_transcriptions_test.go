@@ -0,0 +1,84 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Floris22/go-llm/v2/llmtypes"
+)
+
+// chunkOf transcribes text as one segment with a one second word every second.
+func chunkOf(text string) llmtypes.GroqTranscriptionResponse {
+	fields := strings.Fields(text)
+	chunk := llmtypes.GroqTranscriptionResponse{
+		Text:     " " + text,
+		Duration: float64(len(fields)),
+		Segments: []llmtypes.TranscriptionSegment{{End: float64(len(fields)), Text: " " + text}},
+	}
+	for i, field := range fields {
+		chunk.Words = append(chunk.Words, llmtypes.TranscriptionWord{Word: field, Start: float64(i), End: float64(i + 1)})
+	}
+	return chunk
+}
+
+func TestMergeTranscriptionsOverlap(t *testing.T) {
+	tests := []struct {
+		name         string
+		chunks       []llmtypes.GroqTranscriptionResponse
+		wantText     string
+		wantSegments []llmtypes.TranscriptionSegment
+	}{
+		{
+			name:     "words",
+			chunks:   []llmtypes.GroqTranscriptionResponse{chunkOf("one two three four"), chunkOf("three four five six")},
+			wantText: " one two three four five six",
+			wantSegments: []llmtypes.TranscriptionSegment{
+				{ID: 0, Start: 0, End: 3, Text: " one two three"},
+				{ID: 1, Start: 3, End: 6, Text: " four five six"},
+			},
+		},
+		{
+			name: "segments only",
+			chunks: []llmtypes.GroqTranscriptionResponse{
+				{Text: " one two three four", Duration: 4, Segments: []llmtypes.TranscriptionSegment{
+					{Start: 0, End: 2, Text: " one two"}, {Start: 2, End: 3.4, Text: " three four"},
+				}},
+				{Text: " three four five six", Duration: 4, Segments: []llmtypes.TranscriptionSegment{
+					{Start: 0, End: 1.4, Text: " three four"}, {Start: 1.4, End: 4, Text: " five six"},
+				}},
+			},
+			wantText: " one two three four five six",
+			wantSegments: []llmtypes.TranscriptionSegment{
+				{ID: 0, Start: 0, End: 2, Text: " one two"},
+				{ID: 1, Start: 2, End: 3, Text: " three four"},
+				{ID: 2, Start: 3.4, End: 6, Text: " five six"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			merged := MergeTranscriptions(test.chunks, []float64{0, 2})
+			if merged.Text != test.wantText {
+				t.Errorf("text = %q, want %q", merged.Text, test.wantText)
+			}
+			if len(merged.Segments) != len(test.wantSegments) {
+				t.Fatalf("segments = %+v, want %+v", merged.Segments, test.wantSegments)
+			}
+			for i, segment := range merged.Segments {
+				want := test.wantSegments[i]
+				if segment.ID != want.ID || segment.Start != want.Start || segment.End != want.End || segment.Text != want.Text {
+					t.Errorf("segment %d = %+v, want %+v", i, segment, want)
+				}
+				if i > 0 && segment.Start < merged.Segments[i-1].End {
+					t.Errorf("segment %d starts at %v before the previous one ends at %v", i, segment.Start, merged.Segments[i-1].End)
+				}
+			}
+			for i := 1; i < len(merged.Words); i++ {
+				if merged.Words[i].Start < merged.Words[i-1].End {
+					t.Errorf("word %d %+v overlaps %+v", i, merged.Words[i], merged.Words[i-1])
+				}
+			}
+		})
+	}
+}
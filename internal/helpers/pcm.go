@@ -0,0 +1,277 @@
+package helpers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"time"
+
+	t "github.com/Floris22/go-llm/v2/llmtypes"
+)
+
+// Sample rate of the chunks sent for transcription
+const SampleRate = 16000
+
+// AudioInput is audio to decode and split.
+type AudioInput struct {
+	Reader io.Reader
+
+	// File Reader reads from, passed to ffmpeg so it can seek
+	Path string
+
+	// Format of headerless PCM, when set Reader isn't parsed as a file
+	Raw *t.PCMFormat
+}
+
+// SplitOptions controls SplitAudio.
+type SplitOptions struct {
+	// Cut in detected silence instead of at fixed lengths
+	OnSilence bool
+
+	// Audio repeated at the start of each chunk from the end of the previous one
+	Overlap time.Duration
+}
+
+// AudioChunk is a part of the decoded audio and its start in seconds from the start of the audio.
+type AudioChunk struct {
+	Samples []int16
+	Offset  float64
+}
+
+// WAV returns the chunk as a WAV file.
+func (c AudioChunk) WAV() []byte {
+	return EncodeWAV(c.Samples, SampleRate)
+}
+
+// Seconds returns the duration of the chunk.
+func (c AudioChunk) Seconds() float64 {
+	return float64(len(c.Samples)) / SampleRate
+}
+
+// SplitAudio decodes input to 16kHz mono 16-bit samples while reading it and calls emit
+// with each chunk whose WAV file would reach chunkSize bytes, so only about one chunk is held in memory.
+// Raw PCM and PCM WAV files are decoded natively, other formats with ffmpeg,
+// returning a *t.FFmpegRequiredError when it isn't installed.
+func SplitAudio(input AudioInput, chunkSize int, options SplitOptions, emit func(AudioChunk) error) error {
+	c := &chunker{
+		maxSamples: max(1, int(0.95*float64(chunkSize-44)/2)),
+		overlap:    int(options.Overlap * SampleRate / time.Second),
+		onSilence:  options.OnSilence,
+		emit:       emit,
+	}
+	r := bufio.NewReaderSize(input.Reader, 64*1024)
+	if input.Raw != nil {
+		return decodePCM(r, *input.Raw, c)
+	}
+
+	// Peek errors are returned by the reads that follow
+	header, _ := r.Peek(64 * 1024)
+	format := DetectAudioFormat(header)
+	if format == AudioFormatWAV {
+		pcmFormat, dataSize, read, err := readWAVHeader(r)
+		if err == nil {
+			var data io.Reader = r
+			if dataSize >= 0 {
+				data = io.LimitReader(r, int64(dataSize))
+			}
+			return decodePCM(data, pcmFormat, c)
+		}
+		if !errors.Is(err, errUnsupportedWAV) {
+			return err
+		}
+		return decodeFFmpeg(io.MultiReader(bytes.NewReader(read), r), input.Path, format, c)
+	}
+	return decodeFFmpeg(r, input.Path, format, c)
+}
+
+// decodePCM decodes PCM samples read from r.
+func decodePCM(r io.Reader, format t.PCMFormat, c *chunker) error {
+	read, err := pcmReader(format)
+	if err != nil {
+		return err
+	}
+	sampleSize := format.BitDepth / 8
+	frameSize := format.Channels * sampleSize
+
+	res := newResampler(format.SampleRate)
+	block := make([]byte, 4096*frameSize)
+	mono := make([]float64, 0, 4096)
+	var out []int16
+	for {
+		n, readErr := io.ReadFull(r, block)
+		mono = mono[:0]
+		for frame := 0; frame+frameSize <= n; frame += frameSize {
+			var sum float64
+			for channel := range format.Channels {
+				sum += read(block, frame+channel*sampleSize)
+			}
+			mono = append(mono, sum/float64(format.Channels))
+		}
+		out = res.push(mono, out[:0])
+		if err := c.push(out); err != nil {
+			return err
+		}
+
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+
+	if err := c.push(res.flush(out[:0])); err != nil {
+		return err
+	}
+	return c.flush()
+}
+
+// decodeFFmpeg decodes the first audio stream read from r, or of the file at path when set, using ffmpeg.
+func decodeFFmpeg(r io.Reader, path string, format AudioFormatEnum, c *chunker) error {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return &t.FFmpegRequiredError{Format: string(format)}
+	}
+
+	input := "pipe:0"
+	switch {
+	case path != "":
+		input, r = path, nil
+	case format == AudioFormatMP4:
+		// MP4 files may have their index at the end, which ffmpeg can't seek to in a pipe
+		tempFile, err := os.CreateTemp("", "audio")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tempFile.Name())
+		_, err = io.Copy(tempFile, r)
+		tempFile.Close()
+		if err != nil {
+			return err
+		}
+		input, r = tempFile.Name(), nil
+	}
+
+	cmd := exec.Command(
+		"ffmpeg", "-hide_banner", "-loglevel", "error",
+		"-i", input,
+		"-map", "0:a:0",
+		"-f", "s16le",
+		"-ar", "16000",
+		"-ac", "1",
+		"pipe:1",
+	)
+	cmd.Stdin = r
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	block := make([]byte, 64*1024)
+	samples := make([]int16, 0, len(block)/2)
+	for {
+		n, readErr := io.ReadFull(stdout, block)
+		samples = samples[:n/2]
+		for i := range samples {
+			samples[i] = int16(binary.LittleEndian.Uint16(block[2*i:]))
+		}
+		if err := c.push(samples); err != nil {
+			cmd.Process.Kill()
+			cmd.Wait()
+			return err
+		}
+
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			cmd.Process.Kill()
+			cmd.Wait()
+			return readErr
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("failed to decode audio: %w. Output: %s", err, stderr.String())
+	}
+	return c.flush()
+}
+
+// chunker buffers streamed samples and emits a chunk whenever more than maxSamples are buffered,
+// cut as planned by PlanChunks.
+type chunker struct {
+	maxSamples int
+	overlap    int
+	onSilence  bool
+	emit       func(AudioChunk) error
+
+	// Samples not emitted yet, buf[0] is sample start
+	buf   []int16
+	start int
+}
+
+func (c *chunker) push(samples []int16) error {
+	c.buf = append(c.buf, samples...)
+	for len(c.buf) > c.maxSamples {
+		window := c.buf[:min(len(c.buf), c.maxSamples+1)]
+		var silences []Silence
+		if c.onSilence {
+			silences = FindSilences(window, SampleRate, 300*time.Millisecond)
+		}
+		spans := PlanChunks(len(window), silences, c.maxSamples, c.overlap)
+
+		if err := c.emitSpan(spans[0]); err != nil {
+			return err
+		}
+		c.buf = c.buf[:copy(c.buf, c.buf[spans[1].Start:])]
+		c.start += spans[1].Start
+	}
+	return nil
+}
+
+// flush emits the remaining samples.
+func (c *chunker) flush() error {
+	if len(c.buf) == 0 {
+		return nil
+	}
+	return c.emitSpan(ChunkSpan{Start: 0, End: len(c.buf)})
+}
+
+func (c *chunker) emitSpan(span ChunkSpan) error {
+	return c.emit(AudioChunk{
+		Samples: slices.Clone(c.buf[span.Start:span.End]),
+		Offset:  float64(c.start+span.Start) / SampleRate,
+	})
+}
+
+// WriteWAV writes mono 16-bit samples as a WAV file.
+func WriteWAV(w io.Writer, samples []int16, sampleRate int) error {
+	dataSize := uint32(2 * len(samples))
+	header := make([]byte, 44)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], 36+dataSize)
+	copy(header[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)
+	binary.LittleEndian.PutUint16(header[20:], 1) // PCM
+	binary.LittleEndian.PutUint16(header[22:], 1) // mono
+	binary.LittleEndian.PutUint32(header[24:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(header[28:], uint32(2*sampleRate))
+	binary.LittleEndian.PutUint16(header[32:], 2)
+	binary.LittleEndian.PutUint16(header[34:], 16)
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], dataSize)
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, samples)
+}
@@ -0,0 +1,59 @@
+package helpers
+
+import (
+	"encoding/json/v2"
+	"strings"
+)
+
+const Redacted = "[REDACTED]"
+
+// Redact replaces every secret in s, e.g. API keys.
+func Redact(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, Redacted)
+		}
+	}
+	return s
+}
+
+// RedactJSON replaces the values of the given fields (case-insensitive, at any depth) and every secret in body.
+// Bodies which are not JSON only have their secrets replaced.
+func RedactJSON(body []byte, fields []string, secrets []string) string {
+	if len(fields) > 0 {
+		var value any
+		if err := json.Unmarshal(body, &value); err == nil {
+			if redacted, err := json.Marshal(redactFields(value, fields), json.Deterministic(true)); err == nil {
+				body = redacted
+			}
+		}
+	}
+	return Redact(string(body), secrets)
+}
+
+func redactFields(value any, fields []string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if containsFold(fields, key) {
+				v[key] = Redacted
+			} else {
+				v[key] = redactFields(field, fields)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactFields(item, fields)
+		}
+	}
+	return value
+}
+
+func containsFold(values []string, s string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, s) {
+			return true
+		}
+	}
+	return false
+}
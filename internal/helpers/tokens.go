@@ -0,0 +1,44 @@
+package helpers
+
+import (
+	"encoding/json/v2"
+)
+
+// EstimateTokens roughly estimates the prompt tokens of a request body,
+// assuming 4 bytes per token.
+func EstimateTokens(body []byte) int {
+	return len(body)/4 + 1
+}
+
+// ResponseMeta holds the fields of a chat completions response
+// needed before the response is fully parsed.
+type ResponseMeta struct {
+	// Upstream provider that served or failed the request, "" if unknown
+	Provider string
+
+	// Total tokens used, 0 if unknown
+	Tokens int
+}
+
+// ParseResponseMeta reads the provider and token usage from a chat completions response.
+// For error responses, the provider is read from OpenRouter's error metadata.
+func ParseResponseMeta(resp Response) ResponseMeta {
+	var body struct {
+		Provider string `json:"provider"`
+		Usage    struct {
+			TotalTokens int `json:"total_tokens"`
+		} `json:"usage"`
+		Error struct {
+			Metadata struct {
+				ProviderName string `json:"provider_name"`
+			} `json:"metadata"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(resp.Body, &body); err != nil {
+		return ResponseMeta{}
+	}
+	if resp.StatusCode != 200 {
+		return ResponseMeta{Provider: body.Error.Metadata.ProviderName}
+	}
+	return ResponseMeta{Provider: body.Provider, Tokens: body.Usage.TotalTokens}
+}
@@ -4,72 +4,134 @@ import (
 	"bytes"
 	"context"
 	"encoding/json/v2"
-	"fmt"
-	"io"
+	"errors"
 	"mime/multipart"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	t "github.com/Floris22/go-llm/v2/llmtypes"
+	"github.com/Floris22/go-llm/v2/ratelimit"
 )
 
+// TranscribePost sends a multipart transcription request body.
+// retry is false for the first request and true for its retries.
+type TranscribePost func(ctx context.Context, retry bool, contentType string, body []byte) (Response, error)
+
+// AudioTaskEnum is a Groq audio endpoint, the value is the last element of its path.
+type AudioTaskEnum string
+
+const (
+	// Speech to text in the spoken language
+	AudioTaskTranscribe AudioTaskEnum = "transcriptions"
+
+	// Speech in any language to English text
+	AudioTaskTranslate AudioTaskEnum = "translations"
+)
+
+// TranscribeGroq sends audioURL, or audioBytes as a file named fileName, to a Groq audio endpoint.
+// The extension of fileName tells Groq the audio format.
 func TranscribeGroq(
+	ctx context.Context,
+	task AudioTaskEnum,
 	model string,
 	language string,
-	apiKey string,
 	audioURL *string,
 	audioBytes *[]byte,
+	fileName string,
 	timeOut *int,
+	options *t.TranscriptionOptions,
+	post TranscribePost,
 ) (t.GroqTranscriptionResponse, error) {
 	timeoutValue := 30
 	if timeOut != nil {
 		timeoutValue = *timeOut
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutValue)*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeoutValue)*time.Second)
 	defer cancel()
 
-	if language == "" {
-		language = "en"
-	}
-
 	var buf bytes.Buffer
 	writer := multipart.NewWriter(&buf)
 	writer.WriteField("model", model)
-	writer.WriteField("language", language)
+	if language != "" {
+		writer.WriteField("language", language)
+	}
 	writer.WriteField("response_format", "verbose_json")
+	if options != nil {
+		for _, granularity := range options.TimestampGranularities {
+			writer.WriteField("timestamp_granularities[]", string(granularity))
+		}
+		if options.Prompt != "" {
+			writer.WriteField("prompt", options.Prompt)
+		}
+		if options.Temperature != nil {
+			writer.WriteField("temperature", strconv.FormatFloat(*options.Temperature, 'f', -1, 64))
+		}
+	}
 
 	if audioBytes != nil {
-		var file io.Reader
-		file = bytes.NewReader(*audioBytes)
-		part, _ := writer.CreateFormFile("file", "file.wav")
-		io.Copy(part, file)
+		part, _ := writer.CreateFormFile("file", fileName)
+		part.Write(*audioBytes)
 	} else {
 		writer.WriteField("url", *audioURL)
 	}
 	writer.Close()
 
-	headers := map[string]string{
-		"Content-Type":  writer.FormDataContentType(),
-		"Authorization": "Bearer " + apiKey,
+	retry := false
+	send := func(ctx context.Context) (Response, error) {
+		resp, err := post(ctx, retry, writer.FormDataContentType(), buf.Bytes())
+		retry = true
+		return resp, err
 	}
 
-	respBody, statusCode, err := PostReq(
-		ctx, "https://api.groq.com/openai/v1/audio/transcriptions", headers, buf.Bytes(), nil,
-	)
+	resp, err := send(ctx)
+	if err != nil {
+		return t.GroqTranscriptionResponse{}, err
+	}
+	respBody, statusCode := resp.Body, resp.StatusCode
 	if statusCode != 200 {
 		if statusCode == 429 || statusCode >= 500 {
-			respBody, err = DoGroqWithRetries(ctx, headers, buf.Bytes())
+			respBody, err = DoGroqWithRetries(ctx, send)
+			if errors.Is(err, ratelimit.ErrRateLimited) {
+				return t.GroqTranscriptionResponse{}, err
+			}
 			if err != nil {
-				return t.GroqTranscriptionResponse{}, fmt.Errorf("Groq API failed retry after 5 attempts: %s", string(respBody))
+				return t.GroqTranscriptionResponse{}, RetriesFailedError("Groq", 5, err, respBody)
 			}
 		} else {
-			return t.GroqTranscriptionResponse{}, fmt.Errorf("Groq API returned status code %d with error: %s", statusCode, string(respBody))
+			return t.GroqTranscriptionResponse{}, &t.APIError{API: "Groq", StatusCode: statusCode, Body: string(respBody), Attempts: 1}
 		}
 	}
-	if err != nil {
-		return t.GroqTranscriptionResponse{}, err
-	}
 
 	var response t.GroqTranscriptionResponse
 	err = json.Unmarshal(respBody, &response)
 	return response, err
 }
+
+// PromptTail returns the end of text, at most maxLength bytes, starting at a word boundary.
+// It is used as the prompt of the next chunk to carry over context.
+func PromptTail(text string, maxLength int) string {
+	text = strings.TrimSpace(text)
+	if len(text) <= maxLength {
+		return text
+	}
+	tail := text[len(text)-maxLength:]
+	if i := strings.IndexByte(tail, ' '); i >= 0 {
+		return tail[i+1:]
+	}
+	for len(tail) > 0 && !utf8.RuneStart(tail[0]) {
+		tail = tail[1:]
+	}
+	return tail
+}
+
+// ChunkOptions returns the options for a chunk following a chunk with text previous.
+func ChunkOptions(options *t.TranscriptionOptions, previous string) *t.TranscriptionOptions {
+	if options == nil || !options.CarryOverPrompt || previous == "" {
+		return options
+	}
+	chunkOptions := *options
+	chunkOptions.Prompt = strings.TrimSpace(options.Prompt + " " + PromptTail(previous, 600))
+	return &chunkOptions
+}
@@ -0,0 +1,102 @@
+package helpers
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// Silence is a span of silent samples, End is exclusive.
+type Silence struct {
+	Start int
+	End   int
+}
+
+const vadFrame = 20 * time.Millisecond
+
+// FindSilences returns the spans of at least minDuration in which the energy of the
+// 16-bit mono samples stays below a threshold derived from the audio's noise floor.
+func FindSilences(samples []int16, sampleRate int, minDuration time.Duration) []Silence {
+	frameSize := max(1, int(time.Duration(sampleRate)*vadFrame/time.Second))
+	frames := len(samples) / frameSize
+	if frames == 0 {
+		return nil
+	}
+
+	energies := make([]float64, frames)
+	for i := range frames {
+		energies[i] = frameEnergy(samples[i*frameSize : (i+1)*frameSize])
+	}
+
+	// Speech is well above the quietest frames, clamp for audio that is all speech or all silence
+	sorted := slices.Clone(energies)
+	slices.Sort(sorted)
+	noiseFloor := sorted[frames/10]
+	threshold := min(max(noiseFloor+10, -60), -30)
+
+	minFrames := max(1, int(minDuration/vadFrame))
+	var silences []Silence
+	runStart := -1
+	for i := 0; i <= frames; i++ {
+		silent := i < frames && energies[i] < threshold
+		if silent && runStart < 0 {
+			runStart = i
+		}
+		if !silent && runStart >= 0 {
+			if i-runStart >= minFrames {
+				silences = append(silences, Silence{Start: runStart * frameSize, End: i * frameSize})
+			}
+			runStart = -1
+		}
+	}
+	return silences
+}
+
+// frameEnergy returns the RMS level of samples in dBFS.
+func frameEnergy(samples []int16) float64 {
+	var sum float64
+	for _, sample := range samples {
+		sum += float64(sample) * float64(sample)
+	}
+	rms := math.Sqrt(sum / float64(len(samples)))
+	return 20 * math.Log10(max(rms, 1)/32768)
+}
+
+// ChunkSpan is a span of samples forming one chunk, End is exclusive.
+type ChunkSpan struct {
+	Start int
+	End   int
+}
+
+// PlanChunks splits total samples into chunks of at most maxSamples.
+// Cuts are placed in the middle of the longest silence in the second half of each chunk,
+// or at maxSamples when there is none. Each chunk after the first starts overlap samples before the previous cut.
+func PlanChunks(total int, silences []Silence, maxSamples int, overlap int) []ChunkSpan {
+	maxSamples = max(1, maxSamples)
+	overlap = min(max(0, overlap), maxSamples/4)
+
+	var spans []ChunkSpan
+	start := 0
+	for start < total {
+		limit := start + maxSamples
+		if limit >= total {
+			spans = append(spans, ChunkSpan{Start: start, End: total})
+			break
+		}
+
+		cut, longest := limit, 0
+		for _, silence := range silences {
+			mid := (silence.Start + silence.End) / 2
+			if mid < start+maxSamples/2 || mid > limit {
+				continue
+			}
+			if length := silence.End - silence.Start; length >= longest {
+				cut, longest = mid, length
+			}
+		}
+
+		spans = append(spans, ChunkSpan{Start: start, End: cut})
+		start = cut - overlap
+	}
+	return spans
+}
@@ -0,0 +1,115 @@
+package helpers
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+// tone returns d of a 440 Hz tone, or silence when amplitude is 0.
+func tone(d time.Duration, amplitude float64) []int16 {
+	samples := make([]int16, int(d*SampleRate/time.Second))
+	for i := range samples {
+		samples[i] = int16(amplitude * math.MaxInt16 * math.Sin(2*math.Pi*440*float64(i)/SampleRate))
+	}
+	return samples
+}
+
+func TestFindSilences(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []int16
+		want    []Silence
+	}{
+		{
+			name:    "silence between tones",
+			samples: slices.Concat(tone(time.Second, 0.5), tone(500*time.Millisecond, 0), tone(time.Second, 0.5)),
+			want:    []Silence{{Start: 16000, End: 24000}},
+		},
+		{
+			name: "short silence ignored",
+			samples: slices.Concat(
+				tone(time.Second, 0.5), tone(100*time.Millisecond, 0), tone(time.Second, 0.5),
+				tone(400*time.Millisecond, 0), tone(time.Second, 0.5),
+			),
+			want: []Silence{{Start: 33600, End: 40000}},
+		},
+		{
+			name:    "quiet tone is silence",
+			samples: slices.Concat(tone(time.Second, 0.5), tone(time.Second, 0.001), tone(time.Second, 0.5)),
+			want:    []Silence{{Start: 16000, End: 32000}},
+		},
+		{
+			name:    "all speech",
+			samples: tone(3*time.Second, 0.5),
+		},
+		{
+			name:    "all silence",
+			samples: tone(time.Second, 0),
+			want:    []Silence{{Start: 0, End: 16000}},
+		},
+		{
+			name:    "shorter than a frame",
+			samples: tone(10*time.Millisecond, 0),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := FindSilences(test.samples, SampleRate, 300*time.Millisecond)
+			if !slices.Equal(got, test.want) {
+				t.Errorf("silences = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPlanChunks(t *testing.T) {
+	tests := []struct {
+		name     string
+		total    int
+		silences []Silence
+		overlap  int
+		want     []ChunkSpan
+	}{
+		{
+			name:  "fixed length",
+			total: 100,
+			want:  []ChunkSpan{{0, 40}, {40, 80}, {80, 100}},
+		},
+		{
+			name:    "overlap",
+			total:   100,
+			overlap: 8,
+			want:    []ChunkSpan{{0, 40}, {32, 72}, {64, 100}},
+		},
+		{
+			name:    "overlap capped at a quarter of a chunk",
+			total:   70,
+			overlap: 30,
+			want:    []ChunkSpan{{0, 40}, {30, 70}},
+		},
+		{
+			name:     "cut in the longest silence of the second half",
+			total:    100,
+			silences: []Silence{{5, 15}, {22, 24}, {26, 34}},
+			overlap:  4,
+			want:     []ChunkSpan{{0, 30}, {26, 66}, {62, 100}},
+		},
+		{
+			name:  "fits in one chunk",
+			total: 40,
+			want:  []ChunkSpan{{0, 40}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := PlanChunks(test.total, test.silences, 40, test.overlap)
+			if !slices.Equal(got, test.want) {
+				t.Errorf("chunks = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
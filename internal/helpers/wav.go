@@ -0,0 +1,204 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	t "github.com/Floris22/go-llm/v2/llmtypes"
+)
+
+var errUnsupportedWAV = errors.New("unsupported WAV encoding")
+
+// ParseWAVHeader returns the format of a PCM or IEEE float WAV file and the position and size
+// of its sample data, size is -1 when unknown. header must include everything before the data.
+// Other encodings, e.g. ADPCM, return an error wrapping errUnsupportedWAV.
+func ParseWAVHeader(header []byte) (t.PCMFormat, int, int, error) {
+	r := bytes.NewReader(header)
+	format, size, _, err := readWAVHeader(r)
+	if err != nil {
+		return t.PCMFormat{}, 0, 0, err
+	}
+	return format, len(header) - r.Len(), size, nil
+}
+
+// readWAVHeader reads a WAV file from r up to its sample data like ParseWAVHeader, discarding
+// the chunks it doesn't need while reading so large metadata chunks aren't held in memory.
+// It also returns the RIFF header and fmt chunk it read, followed by the rest of r
+// they are a WAV file without the discarded chunks, e.g. to decode unsupported encodings with ffmpeg.
+func readWAVHeader(r io.Reader) (t.PCMFormat, int, []byte, error) {
+	read := make([]byte, 12)
+	if _, err := io.ReadFull(r, read); err != nil || string(read[0:4]) != "RIFF" || string(read[8:12]) != "WAVE" {
+		return t.PCMFormat{}, 0, nil, errors.New("Not a WAV file.")
+	}
+
+	var format t.PCMFormat
+	hasFormat := false
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return t.PCMFormat{}, 0, read, errors.New("WAV file without data chunk.")
+		}
+		id := string(header[0:4])
+		size := int(binary.LittleEndian.Uint32(header[4:]))
+		// Chunks are padded to an even size
+		padded := size + size%2
+
+		switch id {
+		case "fmt ":
+			if size < 16 || size > 1024 {
+				return t.PCMFormat{}, 0, read, fmt.Errorf("Invalid WAV fmt chunk size %d.", size)
+			}
+			body := make([]byte, padded)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return t.PCMFormat{}, 0, read, errors.New("WAV fmt chunk is too short.")
+			}
+			read = append(append(read, header...), body...)
+
+			encoding := binary.LittleEndian.Uint16(body[0:])
+			// WAVE_FORMAT_EXTENSIBLE stores the encoding in its sub format
+			if encoding == 0xFFFE && size >= 40 {
+				encoding = binary.LittleEndian.Uint16(body[24:])
+			}
+			format = t.PCMFormat{
+				Channels:   int(binary.LittleEndian.Uint16(body[2:])),
+				SampleRate: int(binary.LittleEndian.Uint32(body[4:])),
+				BitDepth:   int(binary.LittleEndian.Uint16(body[14:])),
+			}
+			switch encoding {
+			case 1:
+			case 3:
+				format.Float = true
+			default:
+				return t.PCMFormat{}, 0, read, fmt.Errorf("%w: format tag %#x", errUnsupportedWAV, encoding)
+			}
+			hasFormat = true
+			continue
+		case "data":
+			if !hasFormat {
+				return t.PCMFormat{}, 0, read, errors.New("WAV data chunk before fmt chunk.")
+			}
+			// Streamed WAV files may not know their data size
+			if size == 0 || size == math.MaxUint32 {
+				size = -1
+			}
+			return format, size, read, nil
+		}
+
+		if _, err := io.CopyN(io.Discard, r, int64(padded)); err != nil {
+			return t.PCMFormat{}, 0, read, errors.New("WAV file without data chunk.")
+		}
+	}
+}
+
+// pcmReader returns a function reading the sample at a byte offset of data as a value between -1 and 1.
+func pcmReader(format t.PCMFormat) (func(data []byte, offset int) float64, error) {
+	if format.SampleRate <= 0 || format.Channels <= 0 {
+		return nil, fmt.Errorf("Invalid PCM format: %d Hz, %d channels.", format.SampleRate, format.Channels)
+	}
+
+	switch {
+	case format.Float && format.BitDepth == 32:
+		return func(data []byte, offset int) float64 {
+			return float64(math.Float32frombits(binary.LittleEndian.Uint32(data[offset:])))
+		}, nil
+	case format.Float && format.BitDepth == 64:
+		return func(data []byte, offset int) float64 {
+			return math.Float64frombits(binary.LittleEndian.Uint64(data[offset:]))
+		}, nil
+	case format.Float:
+	case format.BitDepth == 8:
+		return func(data []byte, offset int) float64 {
+			return (float64(data[offset]) - 128) / 128
+		}, nil
+	case format.BitDepth == 16:
+		return func(data []byte, offset int) float64 {
+			return float64(int16(binary.LittleEndian.Uint16(data[offset:]))) / 32768
+		}, nil
+	case format.BitDepth == 24:
+		return func(data []byte, offset int) float64 {
+			value := int32(data[offset]) | int32(data[offset+1])<<8 | int32(int8(data[offset+2]))<<16
+			return float64(value) / (1 << 23)
+		}, nil
+	case format.BitDepth == 32:
+		return func(data []byte, offset int) float64 {
+			return float64(int32(binary.LittleEndian.Uint32(data[offset:]))) / (1 << 31)
+		}, nil
+	}
+	return nil, fmt.Errorf("Unsupported PCM bit depth %d.", format.BitDepth)
+}
+
+// resampler converts mono samples to 16kHz while they are streamed.
+// Downsampling averages the source samples of each output sample, upsampling interpolates linearly.
+type resampler struct {
+	ratio float64
+
+	// Source samples not fully used yet, buf[0] is source sample start
+	buf   []float64
+	start int
+
+	// Index of the next output sample
+	next int
+}
+
+func newResampler(sampleRate int) *resampler {
+	return &resampler{ratio: float64(sampleRate) / SampleRate}
+}
+
+// push adds source samples and appends the output samples they complete to out.
+func (r *resampler) push(samples []float64, out []int16) []int16 {
+	r.buf = append(r.buf, samples...)
+	out = r.resample(out, r.start+len(r.buf), false)
+
+	if used := int(float64(r.next)*r.ratio) - r.start; used > 0 {
+		r.buf = r.buf[:copy(r.buf, r.buf[used:])]
+		r.start += used
+	}
+	return out
+}
+
+// flush appends the remaining output samples to out.
+func (r *resampler) flush(out []int16) []int16 {
+	return r.resample(out, r.start+len(r.buf), true)
+}
+
+func (r *resampler) resample(out []int16, end int, final bool) []int16 {
+	for {
+		var value float64
+		if r.ratio > 1 {
+			from := int(float64(r.next) * r.ratio)
+			to := max(from+1, int(float64(r.next+1)*r.ratio))
+			if to > end && !(final && from < end) {
+				return out
+			}
+			to = min(to, end)
+			for i := from; i < to; i++ {
+				value += r.buf[i-r.start]
+			}
+			value /= float64(to - from)
+		} else {
+			pos := float64(r.next) * r.ratio
+			frame := int(pos)
+			if frame+1 >= end && !(final && frame < end) {
+				return out
+			}
+			value = r.buf[frame-r.start]
+			if frame+1 < end {
+				value += (r.buf[frame+1-r.start] - value) * (pos - float64(frame))
+			}
+		}
+		out = append(out, int16(math.Round(max(-1, min(value, 1))*math.MaxInt16)))
+		r.next++
+	}
+}
+
+// EncodeWAV returns mono 16-bit samples as a WAV file.
+func EncodeWAV(samples []int16, sampleRate int) []byte {
+	var buf bytes.Buffer
+	buf.Grow(44 + 2*len(samples))
+	WriteWAV(&buf, samples, sampleRate)
+	return buf.Bytes()
+}
@@ -0,0 +1,123 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+)
+
+// withChunk returns wav with a padded chunk of size bytes inserted before its data chunk.
+func withChunk(wav []byte, id string, size int) []byte {
+	chunk := make([]byte, 8+size+size%2)
+	copy(chunk, id)
+	binary.LittleEndian.PutUint32(chunk[4:], uint32(size))
+	return append(append(append([]byte{}, wav[:36]...), chunk...), wav[36:]...)
+}
+
+func TestSplitAudioWAVChunks(t *testing.T) {
+	samples := make([]int16, SampleRate)
+	for i := range samples {
+		samples[i] = int16(i)
+	}
+	wav := EncodeWAV(samples, SampleRate)
+
+	tests := []struct {
+		name string
+		file []byte
+	}{
+		{name: "plain", file: wav},
+		{name: "small chunk", file: withChunk(wav, "LIST", 101)},
+		{name: "chunk larger than the peeked header", file: withChunk(wav, "JUNK", 200*1024)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []int16
+			err := SplitAudio(AudioInput{Reader: bytes.NewReader(test.file)}, 1<<20, SplitOptions{}, func(chunk AudioChunk) error {
+				got = append(got, chunk.Samples...)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(samples) || got[len(got)-1] != samples[len(samples)-1] {
+				t.Errorf("decoded %d samples, want %d", len(got), len(samples))
+			}
+		})
+	}
+}
+
+func TestDetectAudioFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		want   AudioFormatEnum
+	}{
+		{name: "wav", header: EncodeWAV(nil, SampleRate), want: AudioFormatWAV},
+		{name: "mp3 with ID3", header: []byte("ID3\x04\x00"), want: AudioFormatMP3},
+		{name: "mpeg 1 layer 3 frame", header: []byte{0xFF, 0xFB, 0x90, 0x00}, want: AudioFormatMP3},
+		{name: "mpeg 2 layer 3 frame", header: []byte{0xFF, 0xF3, 0x90, 0x00}, want: AudioFormatMP3},
+		{name: "aac adts frame", header: []byte{0xFF, 0xF1, 0x50, 0x80}, want: AudioFormatUnknown},
+		{name: "mpeg 2 aac adts frame", header: []byte{0xFF, 0xF9, 0x50, 0x80}, want: AudioFormatUnknown},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := DetectAudioFormat(test.header); got != test.want {
+				t.Errorf("format = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestResampler(t *testing.T) {
+	tests := []struct {
+		sampleRate int
+		blockSize  int
+	}{
+		{sampleRate: 8000, blockSize: 1},
+		{sampleRate: 8000, blockSize: 333},
+		{sampleRate: 16000, blockSize: 4096},
+		{sampleRate: 22050, blockSize: 7},
+		{sampleRate: 44100, blockSize: 1000},
+		{sampleRate: 48000, blockSize: 4096},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d Hz in blocks of %d", test.sampleRate, test.blockSize), func(t *testing.T) {
+			// One second of a 440 Hz tone
+			source := make([]float64, test.sampleRate)
+			for i := range source {
+				source[i] = 0.9 * math.Sin(2*math.Pi*440*float64(i)/float64(test.sampleRate))
+			}
+
+			whole := newResampler(test.sampleRate)
+			want := whole.flush(whole.push(source, nil))
+
+			streamed := newResampler(test.sampleRate)
+			var got []int16
+			for start := 0; start < len(source); start += test.blockSize {
+				got = streamed.push(source[start:min(start+test.blockSize, len(source))], got)
+			}
+			got = streamed.flush(got)
+
+			if len(got) < SampleRate-1 || len(got) > SampleRate+1 {
+				t.Errorf("got %d samples, want %d", len(got), SampleRate)
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("samples pushed in blocks differ from samples pushed at once")
+			}
+			// A 440 Hz tone at 16kHz changes by at most 2π*440/16000 of its amplitude per sample,
+			// averaging windows of uneven length shift samples a little
+			maxStep := 0.9 * 2 * math.Pi * 440 / SampleRate * math.MaxInt16 * 1.25
+			for i := 1; i < len(got); i++ {
+				if step := math.Abs(float64(got[i]) - float64(got[i-1])); step > maxStep {
+					t.Fatalf("sample %d jumps by %.0f, want at most %.0f", i, step, maxStep)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package llmtypes
+
+// Maximum number of cache breakpoints per request allowed by Anthropic
+const MaxCacheBreakpoints = 4
+
+// CacheControl marks a prompt cache breakpoint.
+// Everything up to and including the marked part is cached by the provider.
+// Anthropic allows at most MaxCacheBreakpoints breakpoints per request.
+type CacheControl struct {
+	Type CacheControlTypeEnum `json:"type"`
+
+	// Optional cache lifetime, e.g. "5m" or "1h". Only supported by Anthropic.
+	TTL *string `json:"ttl,omitempty"`
+}
+
+// EphemeralCache returns an ephemeral CacheControl with the provider's default TTL.
+func EphemeralCache() *CacheControl {
+	return &CacheControl{Type: CacheControlEphemeral}
+}
+
+// MarkCacheBreakpoints returns a copy of messages with cacheControl set on the last
+// content part of every system message and of the last lastNTurns non-system messages.
+// At most MaxCacheBreakpoints are set, turns are dropped before system messages
+// and older ones before newer ones.
+// If cacheControl is nil, EphemeralCache() is used. The input slice is not modified.
+func MarkCacheBreakpoints(
+	messages []PartMessageForLLM,
+	lastNTurns int,
+	cacheControl *CacheControl,
+) []PartMessageForLLM {
+	if cacheControl == nil {
+		cacheControl = EphemeralCache()
+	}
+
+	marked := make([]PartMessageForLLM, len(messages))
+	copy(marked, messages)
+
+	systems := 0
+	for _, message := range marked {
+		if message.Role == RoleSystem && len(message.Content) > 0 {
+			systems++
+		}
+	}
+	systemBudget, turnBudget := breakpointBudgets(systems, lastNTurns)
+
+	for i := len(marked) - 1; i >= 0; i-- {
+		if len(marked[i].Content) == 0 {
+			continue
+		}
+		if marked[i].Role == RoleSystem {
+			if systemBudget == 0 {
+				continue
+			}
+			systemBudget--
+		} else {
+			if turnBudget == 0 {
+				continue
+			}
+			turnBudget--
+		}
+		content := make([]ContentPart, len(marked[i].Content))
+		copy(content, marked[i].Content)
+		content[len(content)-1].CacheControl = cacheControl
+		marked[i].Content = content
+	}
+	return marked
+}
+
+// MarkMessageCacheBreakpoints is the MessageForLLM variant of MarkCacheBreakpoints.
+// It sets cacheControl on every system message and on the last lastNTurns non-system
+// messages that have content, at most MaxCacheBreakpoints in total. The input slice is not modified.
+func MarkMessageCacheBreakpoints(
+	messages []MessageForLLM,
+	lastNTurns int,
+	cacheControl *CacheControl,
+) []MessageForLLM {
+	if cacheControl == nil {
+		cacheControl = EphemeralCache()
+	}
+
+	marked := make([]MessageForLLM, len(messages))
+	copy(marked, messages)
+
+	systems := 0
+	for _, message := range marked {
+		if message.Role == RoleSystem {
+			systems++
+		}
+	}
+	systemBudget, turnBudget := breakpointBudgets(systems, lastNTurns)
+
+	for i := len(marked) - 1; i >= 0; i-- {
+		if marked[i].Role == RoleSystem && systemBudget > 0 {
+			marked[i].CacheControl = cacheControl
+			systemBudget--
+		} else if marked[i].Role != RoleSystem && turnBudget > 0 && marked[i].Content != nil {
+			marked[i].CacheControl = cacheControl
+			turnBudget--
+		}
+	}
+	return marked
+}
+
+// breakpointBudgets splits MaxCacheBreakpoints between system messages and turns,
+// system messages first as they are the most stable prefix.
+func breakpointBudgets(systems int, lastNTurns int) (int, int) {
+	systemBudget := min(systems, MaxCacheBreakpoints)
+	turnBudget := min(max(0, lastNTurns), MaxCacheBreakpoints-systemBudget)
+	return systemBudget, turnBudget
+}
@@ -0,0 +1,79 @@
+package llmtypes
+
+import "testing"
+
+func TestMarkCacheBreakpointsLimit(t *testing.T) {
+	text := func(role RoleEnum) PartMessageForLLM {
+		return PartMessageForLLM{Role: role, Content: []ContentPart{{Type: "text", Text: "x"}}}
+	}
+
+	tests := []struct {
+		name       string
+		messages   []PartMessageForLLM
+		lastNTurns int
+		want       []bool
+	}{
+		{
+			name:       "within limit",
+			messages:   []PartMessageForLLM{text(RoleSystem), text(RoleUser), text(RoleAssistant), text(RoleUser)},
+			lastNTurns: 2,
+			want:       []bool{true, false, true, true},
+		},
+		{
+			name: "oldest turns dropped",
+			messages: []PartMessageForLLM{
+				text(RoleSystem), text(RoleSystem), text(RoleUser), text(RoleAssistant), text(RoleUser),
+			},
+			lastNTurns: 3,
+			want:       []bool{true, true, false, true, true},
+		},
+		{
+			name: "only newest system messages",
+			messages: []PartMessageForLLM{
+				text(RoleSystem), text(RoleSystem), text(RoleSystem), text(RoleSystem), text(RoleSystem), text(RoleUser),
+			},
+			lastNTurns: 1,
+			want:       []bool{false, true, true, true, true, false},
+		},
+		{
+			name:       "empty messages skipped",
+			messages:   []PartMessageForLLM{text(RoleSystem), text(RoleUser), {Role: RoleAssistant}},
+			lastNTurns: 1,
+			want:       []bool{true, true, false},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			marked := MarkCacheBreakpoints(test.messages, test.lastNTurns, nil)
+			for i, message := range marked {
+				got := len(message.Content) > 0 && message.Content[len(message.Content)-1].CacheControl != nil
+				if got != test.want[i] {
+					t.Errorf("message %d marked = %v, want %v", i, got, test.want[i])
+				}
+				if len(test.messages[i].Content) > 0 && test.messages[i].Content[0].CacheControl != nil {
+					t.Errorf("message %d of the input was modified", i)
+				}
+			}
+		})
+	}
+}
+
+func TestMarkMessageCacheBreakpointsLimit(t *testing.T) {
+	content := "x"
+	messages := []MessageForLLM{
+		{Role: RoleSystem, Content: &content},
+		{Role: RoleSystem, Content: &content},
+		{Role: RoleUser, Content: &content},
+		{Role: RoleAssistant, Content: &content},
+		{Role: RoleUser, Content: &content},
+	}
+	want := []bool{true, true, false, true, true}
+
+	marked := MarkMessageCacheBreakpoints(messages, 3, nil)
+	for i, message := range marked {
+		if got := message.CacheControl != nil; got != want[i] {
+			t.Errorf("message %d marked = %v, want %v", i, got, want[i])
+		}
+	}
+}
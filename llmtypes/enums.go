@@ -31,3 +31,103 @@ const (
 	// Let model decide which tools to use
 	ToolChoiceAuto ToolChoiceEnum = "auto"
 )
+
+type CacheControlTypeEnum string
+
+const (
+	// The only cache type supported by OpenRouter
+	CacheControlEphemeral CacheControlTypeEnum = "ephemeral"
+)
+
+type PluginIDEnum string
+
+const (
+	// Augments the prompt with web search results
+	PluginWeb PluginIDEnum = "web"
+
+	// Parses PDF files sent as file content parts
+	PluginFileParser PluginIDEnum = "file-parser"
+
+	// Repairs malformed JSON in structured output responses
+	PluginResponseHealing PluginIDEnum = "response-healing"
+)
+
+type WebSearchEngineEnum string
+
+const (
+	// Use the provider's built-in web search, if available
+	WebSearchNative WebSearchEngineEnum = "native"
+
+	// Use Exa for web search
+	WebSearchExa WebSearchEngineEnum = "exa"
+)
+
+type PDFEngineEnum string
+
+const (
+	// Extract embedded text only, free
+	PDFEngineText PDFEngineEnum = "pdf-text"
+
+	// OCR with Mistral, for scanned documents
+	PDFEngineMistralOCR PDFEngineEnum = "mistral-ocr"
+
+	// Let the model read the PDF natively, if supported
+	PDFEngineNative PDFEngineEnum = "native"
+)
+
+type ProviderSortPartitionEnum string
+
+const (
+	// Sort providers within each model (default)
+	PartitionModel ProviderSortPartitionEnum = "model"
+
+	// Sort providers across all models
+	PartitionNone ProviderSortPartitionEnum = "none"
+)
+
+type DataCollectionEnum string
+
+const (
+	// Allow providers that may store or train on your data
+	DataCollectionAllow DataCollectionEnum = "allow"
+
+	// Only use providers that don't collect your data
+	DataCollectionDeny DataCollectionEnum = "deny"
+)
+
+type QuantizationEnum string
+
+const (
+	QuantizationInt4    QuantizationEnum = "int4"
+	QuantizationInt8    QuantizationEnum = "int8"
+	QuantizationFP4     QuantizationEnum = "fp4"
+	QuantizationFP6     QuantizationEnum = "fp6"
+	QuantizationFP8     QuantizationEnum = "fp8"
+	QuantizationFP16    QuantizationEnum = "fp16"
+	QuantizationBF16    QuantizationEnum = "bf16"
+	QuantizationFP32    QuantizationEnum = "fp32"
+	QuantizationUnknown QuantizationEnum = "unknown"
+)
+
+type TimeoutPhaseEnum string
+
+const (
+	// No connection to the API was established in time
+	TimeoutPhaseConnect TimeoutPhaseEnum = "connect"
+
+	// The API didn't send the first byte of its response in time
+	TimeoutPhaseFirstToken TimeoutPhaseEnum = "first_token"
+
+	// The whole call, including retries and fallbacks, didn't finish in time
+	TimeoutPhaseTotal TimeoutPhaseEnum = "total"
+)
+
+type TimestampGranularityEnum string
+
+const (
+	// Timestamps per segment, returned by default
+	TimestampGranularitySegment TimestampGranularityEnum = "segment"
+
+	// Timestamps per word
+	TimestampGranularityWord TimestampGranularityEnum = "word"
+)
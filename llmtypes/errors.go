@@ -0,0 +1,96 @@
+package llmtypes
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// TimeoutError is returned when a request exceeds one of its deadlines.
+// It wraps context.DeadlineExceeded, so errors.Is(err, context.DeadlineExceeded) still works.
+type TimeoutError struct {
+	Phase   TimeoutPhaseEnum
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("request exceeded the %s timeout of %s", e.Phase, e.Timeout)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// APIError is returned when an API responds with an error status.
+type APIError struct {
+	// API that responded, e.g. "OpenRouter" or "Groq"
+	API string
+
+	// Status of the last response, 0 if the last attempt got no response
+	StatusCode int
+	Body       string
+
+	// Requests sent before giving up, more than 1 when the status was retried
+	Attempts int
+}
+
+func (e *APIError) Error() string {
+	if e.Attempts > 1 {
+		return fmt.Sprintf("%s API failed retry after %d attempts: %s", e.API, e.Attempts, e.Body)
+	}
+	return fmt.Sprintf("%s API returned status code %d with error: %s", e.API, e.StatusCode, e.Body)
+}
+
+// ChunkError is the failure of one audio chunk of a transcription.
+type ChunkError struct {
+	// Position of the chunk, starting at 0
+	Index int
+
+	Err error
+}
+
+func (e *ChunkError) Error() string {
+	return fmt.Sprintf("audio chunk %d failed: %v", e.Index, e.Err)
+}
+
+func (e *ChunkError) Unwrap() error {
+	return e.Err
+}
+
+// PartialTranscriptionError is returned when some audio chunks of a transcription failed.
+// Transcription merges the chunks that succeeded, the failed chunks are gaps in its timeline.
+type PartialTranscriptionError struct {
+	Transcription GroqTranscriptionResponse
+	Failed        []*ChunkError
+
+	// Number of chunks of the audio
+	Total int
+}
+
+func (e *PartialTranscriptionError) Error() string {
+	return fmt.Sprintf("%d of %d audio chunks failed, first error: %v", len(e.Failed), e.Total, e.Failed[0])
+}
+
+func (e *PartialTranscriptionError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+	for i, err := range e.Failed {
+		errs[i] = err
+	}
+	return errs
+}
+
+// FFmpegRequiredError is returned when audio has to be decoded with ffmpeg, which isn't installed.
+// WAV and raw PCM audio are decoded without ffmpeg.
+type FFmpegRequiredError struct {
+	// Detected format of the audio, e.g. "mp3"
+	Format string
+}
+
+func (e *FFmpegRequiredError) Error() string {
+	return fmt.Sprintf("decoding %s audio requires ffmpeg, which was not found in PATH", e.Format)
+}
+
+func (e *FFmpegRequiredError) Unwrap() error {
+	return exec.ErrNotFound
+}
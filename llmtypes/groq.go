@@ -1,6 +1,94 @@
 package llmtypes
 
+import "time"
+
 type GroqTranscriptionResponse struct {
 	Text     string  `json:"text"`
 	Duration float64 `json:"duration"`
+
+	// Language of the audio, e.g. "English".
+	// Detected by the API when no language is given.
+	Language string `json:"language,omitempty"`
+
+	Segments []TranscriptionSegment `json:"segments,omitempty"`
+
+	// Only set when TimestampGranularityWord is requested
+	Words []TranscriptionWord `json:"words,omitempty"`
+}
+
+// TranscriptionSegment is a segment of a transcription, times are in seconds from the start of the audio.
+type TranscriptionSegment struct {
+	ID    int     `json:"id"`
+	Seek  int     `json:"seek"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+
+	Tokens      []int   `json:"tokens,omitempty"`
+	Temperature float64 `json:"temperature"`
+
+	// Average log probability of the tokens, below -1 indicates a poor transcription
+	AvgLogprob float64 `json:"avg_logprob"`
+
+	// Compression ratio of the text, above 2.4 indicates repetitive output
+	CompressionRatio float64 `json:"compression_ratio"`
+
+	// Probability that the segment contains no speech
+	NoSpeechProb float64 `json:"no_speech_prob"`
+}
+
+// TranscriptionWord is a word of a transcription, times are in seconds from the start of the audio.
+type TranscriptionWord struct {
+	Word  string  `json:"word"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// TranscriptionOptions holds the optional settings of a transcription.
+type TranscriptionOptions struct {
+	// Timestamps to return, defaults to segments only
+	TimestampGranularities []TimestampGranularityEnum
+
+	// Text to guide the style or continue a previous segment, e.g. names and jargon spelled correctly.
+	// Should match the language of the audio, only the last 224 tokens are used.
+	Prompt string
+
+	// Sampling temperature between 0 and 1, defaults to 0
+	Temperature *float64
+
+	// Append the end of each chunk's text to the prompt of the next chunk,
+	// improving continuity when audio is split into chunks.
+	// Chunks are then transcribed one at a time.
+	CarryOverPrompt bool
+
+	// Maximum chunks transcribed at the same time, defaults to 4
+	Concurrency int
+
+	// Maximum times each chunk is sent after timeouts and network errors, defaults to 3
+	ChunkAttempts int
+
+	// Format of audio bytes without a header, e.g. raw PCM from a microphone.
+	// Nil for audio files, WAV is decoded natively and other formats with ffmpeg.
+	RawPCM *PCMFormat
+
+	// Cut audio into chunks in detected silence instead of at fixed lengths, so words aren't cut in half
+	SplitOnSilence bool
+
+	// Audio repeated at the start of each chunk from the end of the previous one, e.g. 2 seconds.
+	// Text transcribed twice is removed using word timestamps, which are requested automatically.
+	ChunkOverlap time.Duration
+
+	// Called after each chunk finished, successful or not. Calls are not concurrent.
+	// While audio is still being read, total is the number of chunks split so far.
+	OnProgress func(completed int, total int)
+}
+
+// PCMFormat describes little-endian PCM samples, interleaved by channel.
+type PCMFormat struct {
+	SampleRate int
+	Channels   int
+
+	// 8 (unsigned), 16, 24 or 32 (signed), or 32 and 64 with Float
+	BitDepth int
+	Float    bool
 }
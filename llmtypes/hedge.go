@@ -0,0 +1,42 @@
+package llmtypes
+
+import "time"
+
+// HedgeReport describes the requests made for a hedged call.
+type HedgeReport struct {
+	// Index in Attempts of the request whose response was returned
+	Winner int
+
+	// The original request first, followed by the hedge request if it was launched
+	Attempts []HedgeAttempt
+}
+
+type HedgeAttempt struct {
+	Model string
+
+	// Upstream provider, "" if the request didn't complete
+	Provider string
+
+	// Generation ID, "" if the request didn't complete
+	ID string
+
+	Duration time.Duration
+
+	// The request was cancelled because the other request won.
+	// OpenRouter may still bill a cancelled request, its cost isn't known.
+	Cancelled bool
+
+	// Usage of the request, nil if it didn't complete
+	Usage *OpenRouterUsage
+}
+
+// KnownCost returns the summed cost of all attempts that reported one.
+func (r *HedgeReport) KnownCost() float64 {
+	var total float64
+	for _, attempt := range r.Attempts {
+		if attempt.Usage != nil && attempt.Usage.Cost != nil {
+			total += *attempt.Usage.Cost
+		}
+	}
+	return total
+}
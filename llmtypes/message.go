@@ -7,6 +7,11 @@ type MessageForLLM struct {
 	Content    *string                  `json:"content,omitempty"`
 	ToolCalls  []MessageForLLMToolCalls `json:"tool_calls,omitempty"`
 	ToolCallID *string                  `json:"tool_call_id,omitempty"`
+
+	// Marks this message as a prompt cache breakpoint.
+	// Providers that only accept cache_control on content parts ignore this,
+	// use PartMessageForLLM in that case.
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
 }
 
 type MessageForLLMToolCalls struct {
@@ -31,8 +36,20 @@ type ContentPart struct {
 	Type     string       `json:"type"`
 	Text     string       `json:"text,omitempty"`
 	ImageURL *ImageStruct `json:"image_url,omitempty"`
+	File     *FileStruct  `json:"file,omitempty"`
+
+	// Marks everything up to and including this part as cacheable.
+	// Anthropic and Gemini models need this to cache a prompt.
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
 }
 
 type ImageStruct struct {
 	URL string `json:"url"`
 }
+
+// FileStruct is used with the "file" content part type, e.g. for PDFs.
+// FileData is either a URL or a base64 data URL ("data:application/pdf;base64,...").
+type FileStruct struct {
+	Filename string `json:"filename"`
+	FileData string `json:"file_data"`
+}
@@ -10,6 +10,7 @@ type OpenRouterRequest struct {
 	ToolChoice     *ToolChoiceEnum  `json:"tool_choice,omitempty"`
 	Reasoning      *ReasoningConfig `json:"reasoning,omitempty"`
 	Provider       *ProviderConfig  `json:"provider,omitempty"`
+	Plugins        []Plugin         `json:"plugins,omitempty"`
 }
 
 type OpenRouterRequestWithParts struct {
@@ -22,25 +23,53 @@ type OpenRouterRequestWithParts struct {
 	ToolChoice     *ToolChoiceEnum     `json:"tool_choice,omitempty"`
 	Reasoning      *ReasoningConfig    `json:"reasoning,omitempty"`
 	Provider       *ProviderConfig     `json:"provider,omitempty"`
+	Plugins        []Plugin            `json:"plugins,omitempty"`
 }
 
 type OpenRouterResponse struct {
-	Provider string `json:"provider"`
-	Model    string `json:"model"`
-	Created  int64  `json:"created"`
-	Choices  []struct {
-		FinishReason string `json:"finish_reason"`
-		Message      struct {
-			Role      RoleEnum                 `json:"role"`
-			Content   string                   `json:"content"`
-			Refusal   *string                  `json:"refusal"`
-			Reasoning *string                  `json:"reasoning"`
-			ToolCalls []MessageForLLMToolCalls `json:"tool_calls,omitempty"`
-		} `json:"message"`
-	} `json:"choices"`
-	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage"`
+	// Generation ID, can be used to look up the generation's stats and cost
+	ID       string             `json:"id"`
+	Provider string             `json:"provider"`
+	Model    string             `json:"model"`
+	Created  int64              `json:"created"`
+	Choices  []OpenRouterChoice `json:"choices"`
+	Usage    OpenRouterUsage    `json:"usage"`
+
+	// Set when the client has hedging enabled, not part of the API response
+	Hedge *HedgeReport `json:"-"`
+
+	// The response was served from the client's response cache, not part of the API response
+	CacheHit bool `json:"-"`
+}
+
+type OpenRouterChoice struct {
+	FinishReason string                    `json:"finish_reason"`
+	Message      OpenRouterResponseMessage `json:"message"`
+}
+
+type OpenRouterResponseMessage struct {
+	Role      RoleEnum                 `json:"role"`
+	Content   string                   `json:"content"`
+	Refusal   *string                  `json:"refusal"`
+	Reasoning *string                  `json:"reasoning"`
+	ToolCalls []MessageForLLMToolCalls `json:"tool_calls,omitempty"`
+
+	// Citations added by the web search plugin
+	Annotations []Annotation `json:"annotations,omitempty"`
+}
+
+type OpenRouterUsage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	TotalTokens         int `json:"total_tokens"`
+	PromptTokensDetails struct {
+		// Prompt tokens read from the provider's prompt cache
+		CachedTokens int `json:"cached_tokens"`
+
+		// Prompt tokens written to the provider's prompt cache (Anthropic only)
+		CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
+	} `json:"prompt_tokens_details"`
+
+	// Cost of the request in credits (USD)
+	Cost *float64 `json:"cost,omitempty"`
 }
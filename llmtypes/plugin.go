@@ -0,0 +1,67 @@
+package llmtypes
+
+// Plugin defines an OpenRouter plugin to enable for a request.
+// Use WebSearchPlugin, FileParserPlugin or ResponseHealingPlugin to create one.
+type Plugin struct {
+	ID PluginIDEnum `json:"id"`
+
+	// Web search only. Defaults to native search when available, exa otherwise.
+	Engine *WebSearchEngineEnum `json:"engine,omitempty"`
+
+	// Web search only. Number of results to add, OpenRouter defaults to 5.
+	MaxResults *int `json:"max_results,omitempty"`
+
+	// Web search only. Prompt used to attach the results to the conversation.
+	SearchPrompt *string `json:"search_prompt,omitempty"`
+
+	// File parser only.
+	PDF *PDFPluginConfig `json:"pdf,omitempty"`
+}
+
+type PDFPluginConfig struct {
+	Engine PDFEngineEnum `json:"engine"`
+}
+
+// WebSearchPlugin returns a web search plugin. All parameters are optional.
+// Appending ":online" to the model name (see OnlineModel) is a shortcut for this plugin with defaults.
+func WebSearchPlugin(engine *WebSearchEngineEnum, maxResults *int, searchPrompt *string) Plugin {
+	return Plugin{
+		ID:           PluginWeb,
+		Engine:       engine,
+		MaxResults:   maxResults,
+		SearchPrompt: searchPrompt,
+	}
+}
+
+// FileParserPlugin returns a file parser plugin using the given PDF engine.
+func FileParserPlugin(engine PDFEngineEnum) Plugin {
+	return Plugin{
+		ID:  PluginFileParser,
+		PDF: &PDFPluginConfig{Engine: engine},
+	}
+}
+
+// ResponseHealingPlugin returns a plugin that repairs malformed structured output.
+func ResponseHealingPlugin() Plugin {
+	return Plugin{ID: PluginResponseHealing}
+}
+
+// OnlineModel returns the model name with the ":online" suffix,
+// which enables web search with default settings.
+func OnlineModel(model string) string {
+	return model + ":online"
+}
+
+// Annotation is attached to a response message, e.g. a web search citation.
+type Annotation struct {
+	Type        string       `json:"type"`
+	URLCitation *URLCitation `json:"url_citation,omitempty"`
+}
+
+type URLCitation struct {
+	URL        string `json:"url"`
+	Title      string `json:"title"`
+	Content    string `json:"content,omitempty"`
+	StartIndex int    `json:"start_index"`
+	EndIndex   int    `json:"end_index"`
+}
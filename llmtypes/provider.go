@@ -1,21 +1,32 @@
 package llmtypes
 
+import (
+	"encoding/json/v2"
+)
+
 // ProviderConfig defines the configuration for a provider for OpenRouter.
+// Every field is optional, nil fields are omitted so OpenRouter uses its default.
 type ProviderConfig struct {
 	// List of providers to use, in order of priority
 	Order *[]string `json:"order,omitempty"`
 
-	// To allow use of other providers if none of your chosen providers are available
-	AllowFallbacks bool `json:"allow_fallbacks"`
+	// To allow use of other providers if none of your chosen providers are available.
+	// OpenRouter default: true
+	AllowFallbacks *bool `json:"allow_fallbacks,omitempty"`
 
-	// Only allow providers that require your set parameters (structured output, reasoning, etc)
-	RequireParameters bool `json:"require_parameters"`
+	// Only allow providers that require your set parameters (structured output, reasoning, etc).
+	// OpenRouter default: false
+	RequireParameters *bool `json:"require_parameters,omitempty"`
 
-	// Allow providers to collect data or not
-	DataCollection bool `json:"data_collection"`
+	// Allow providers to collect data or not.
+	// OpenRouter default: allow
+	DataCollection *DataCollectionEnum `json:"data_collection,omitempty"`
 
 	// Zero Data Retention providers only.
-	ZDR bool `json:"zdr"`
+	ZDR *bool `json:"zdr,omitempty"`
+
+	// Only use providers that allow text distillation of the model's output.
+	EnforceDistillableText *bool `json:"enforce_distillable_text,omitempty"`
 
 	// List of providers to use, no others will be used if this is set
 	Only *[]string `json:"only,omitempty"`
@@ -23,6 +34,59 @@ type ProviderConfig struct {
 	// List of providers to ignore
 	Ignore *[]string `json:"ignore,omitempty"`
 
+	// Only use providers serving the model at one of these quantization levels
+	Quantizations *[]QuantizationEnum `json:"quantizations,omitempty"`
+
 	// Sort providers by price, throughput, or latency
-	Sort *ProviderSortEnum `json:"sort,omitempty"`
+	Sort *ProviderSort `json:"sort,omitempty"`
+
+	// Maximum price you are willing to pay, providers above it are skipped
+	MaxPrice *ProviderMaxPrice `json:"max_price,omitempty"`
+}
+
+// ProviderSort sorts the providers of a request.
+// Without a Partition it is sent as a plain string, e.g. "price".
+type ProviderSort struct {
+	By ProviderSortEnum `json:"by"`
+
+	// When using multiple models, "model" (default) sorts providers within each model,
+	// "none" sorts the providers of all models together.
+	Partition *ProviderSortPartitionEnum `json:"partition,omitempty"`
+}
+
+// SortBy returns a ProviderSort without partitioning.
+func SortBy(sort ProviderSortEnum) *ProviderSort {
+	return &ProviderSort{By: sort}
+}
+
+func (s ProviderSort) MarshalJSON() ([]byte, error) {
+	if s.Partition == nil {
+		return json.Marshal(string(s.By))
+	}
+	type sortObject ProviderSort
+	return json.Marshal(sortObject(s))
+}
+
+func (s *ProviderSort) UnmarshalJSON(data []byte) error {
+	var by string
+	if err := json.Unmarshal(data, &by); err == nil {
+		*s = ProviderSort{By: ProviderSortEnum(by)}
+		return nil
+	}
+	type sortObject ProviderSort
+	var obj sortObject
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*s = ProviderSort(obj)
+	return nil
+}
+
+// ProviderMaxPrice defines price limits in USD.
+// Prompt and Completion are per million tokens, Request per request and Image per image.
+type ProviderMaxPrice struct {
+	Prompt     *float64 `json:"prompt,omitempty"`
+	Completion *float64 `json:"completion,omitempty"`
+	Request    *float64 `json:"request,omitempty"`
+	Image      *float64 `json:"image,omitempty"`
 }
@@ -0,0 +1,440 @@
+// Package mockserver provides an httptest based server speaking the OpenRouter and Groq APIs,
+// so the real HTTP, retry and parsing paths of the clients can be tested without external services.
+// Point a client at it with OpenRouterConfig.BaseURL = server.OpenRouterURL()
+// and GroqConfig.BaseURL = server.GroqURL().
+package mockserver
+
+import (
+	"bytes"
+	"encoding/json/v2"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	t "github.com/Floris22/go-llm/v2/llmtypes"
+)
+
+type RouteEnum string
+
+const (
+	RouteChatCompletions    RouteEnum = "/api/v1/chat/completions"
+	RouteModels             RouteEnum = "/api/v1/models"
+	RouteGeneration         RouteEnum = "/api/v1/generation"
+	RouteGroqTranscriptions RouteEnum = "/openai/v1/audio/transcriptions"
+	RouteGroqTranslations   RouteEnum = "/openai/v1/audio/translations"
+	RouteGroqChat           RouteEnum = "/openai/v1/chat/completions"
+)
+
+// Scenario defines how the server answers a request.
+// The zero value answers with a successful default response.
+type Scenario struct {
+	// Defaults to 200
+	StatusCode int
+
+	// Raw response body. If empty, a default body for the route is generated:
+	// a chat completion, model list, generation or transcription on success,
+	// an OpenRouter style error otherwise.
+	Body string
+
+	Headers map[string]string
+
+	// Sets the Retry-After header, in whole seconds
+	RetryAfter time.Duration
+
+	// Time to wait before sending the response headers
+	Delay time.Duration
+
+	// Content of a generated chat completion or text of a generated transcription
+	Text string
+
+	// Tool calls of a generated chat completion
+	ToolCalls []t.MessageForLLMToolCalls
+
+	// Answer chat completions with the content of the last message
+	Echo bool
+
+	// Send a body that isn't valid JSON
+	Malformed bool
+
+	// Send a generated chat completion as server-sent events, one word per event.
+	// Requests with "stream": true are always streamed.
+	Stream bool
+
+	// Time between streamed events
+	ChunkDelay time.Duration
+}
+
+// Request is a request received by the server.
+type Request struct {
+	Route  RouteEnum
+	Method string
+	Header http.Header
+	Query  string
+	Body   []byte
+}
+
+// Server is a mock OpenRouter and Groq API server. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	queues    map[RouteEnum][]Scenario
+	defaults  map[RouteEnum]Scenario
+	requests  []Request
+	models    []string
+	generated int
+}
+
+// New starts a Server, call Close when done.
+func New() *Server {
+	s := &Server{
+		queues:   map[RouteEnum][]Scenario{},
+		defaults: map[RouteEnum]Scenario{},
+		models:   []string{"openai/gpt-oss-120b", "google/gemini-2.5-flash"},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// OpenRouterURL returns the base URL for OpenRouterConfig.BaseURL.
+func (s *Server) OpenRouterURL() string {
+	return s.URL + "/api/v1"
+}
+
+// GroqURL returns the base URL for GroqConfig.BaseURL.
+func (s *Server) GroqURL() string {
+	return s.URL + "/openai/v1"
+}
+
+// Enqueue adds scenarios for route, used once each in order.
+// Once they are used up the route's default scenario is used.
+func (s *Server) Enqueue(route RouteEnum, scenarios ...Scenario) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queues[route] = append(s.queues[route], scenarios...)
+}
+
+// SetDefault sets the scenario used for route when its queue is empty.
+func (s *Server) SetDefault(route RouteEnum, scenario Scenario) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defaults[route] = scenario
+}
+
+// SetModels sets the model IDs returned by the models route.
+func (s *Server) SetModels(models ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.models = models
+}
+
+// Requests returns all requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	requests := make([]Request, len(s.requests))
+	copy(requests, s.requests)
+	return requests
+}
+
+// RequestsFor returns the requests received for route.
+func (s *Server) RequestsFor(route RouteEnum) []Request {
+	var requests []Request
+	for _, req := range s.Requests() {
+		if req.Route == route {
+			requests = append(requests, req)
+		}
+	}
+	return requests
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	route := RouteEnum(r.URL.Path)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Route:  route,
+		Method: r.Method,
+		Header: r.Header.Clone(),
+		Query:  r.URL.RawQuery,
+		Body:   body,
+	})
+	scenario := s.defaults[route]
+	if queue := s.queues[route]; len(queue) > 0 {
+		scenario, s.queues[route] = queue[0], queue[1:]
+	}
+	s.generated++
+	id := fmt.Sprintf("gen-mock-%d", s.generated)
+	models := s.models
+	s.mu.Unlock()
+
+	switch route {
+	case RouteChatCompletions, RouteModels, RouteGeneration, RouteGroqTranscriptions, RouteGroqTranslations, RouteGroqChat:
+	default:
+		scenario = Scenario{StatusCode: http.StatusNotFound}
+	}
+
+	if scenario.Delay > 0 {
+		select {
+		case <-time.After(scenario.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	statusCode := scenario.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	for key, value := range scenario.Headers {
+		w.Header().Set(key, value)
+	}
+	if scenario.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(scenario.RetryAfter.Seconds())))
+	}
+
+	switch {
+	case scenario.Malformed:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		w.Write([]byte(`{"id": "` + id + `", "choices": [`))
+	case scenario.Body != "":
+		w.WriteHeader(statusCode)
+		w.Write([]byte(scenario.Body))
+	case statusCode != http.StatusOK:
+		writeJSON(w, statusCode, errorBody(statusCode))
+	case route == RouteChatCompletions, route == RouteGroqChat:
+		s.chatCompletion(w, r, id, body, scenario)
+	case route == RouteModels:
+		writeJSON(w, statusCode, modelsBody(models))
+	case route == RouteGeneration:
+		writeJSON(w, statusCode, generationBody(r.URL.Query().Get("id")))
+	case route == RouteGroqTranscriptions:
+		writeJSON(w, statusCode, transcriptionBody(r, scenario, "transcribe"))
+	case route == RouteGroqTranslations:
+		writeJSON(w, statusCode, transcriptionBody(r, scenario, "translate"))
+	}
+}
+
+// chatCompletion writes a generated chat completion, streamed if requested.
+// Only OpenRouter responses name the upstream provider.
+func (s *Server) chatCompletion(w http.ResponseWriter, r *http.Request, id string, body []byte, scenario Scenario) {
+	var req struct {
+		Model    string `json:"model"`
+		Stream   bool   `json:"stream"`
+		Messages []struct {
+			Content any `json:"content"`
+		} `json:"messages"`
+	}
+	json.Unmarshal(body, &req)
+
+	content := scenario.Text
+	if content == "" && scenario.ToolCalls == nil {
+		content = "This is a mock response."
+	}
+	if scenario.Echo && len(req.Messages) > 0 {
+		content = messageText(req.Messages[len(req.Messages)-1].Content)
+	}
+
+	finishReason := "stop"
+	if scenario.ToolCalls != nil {
+		finishReason = "tool_calls"
+	}
+	promptTokens := len(body) / 4
+	completionTokens := len(strings.Fields(content)) + 1
+	var provider any = "Mock"
+	if RouteEnum(r.URL.Path) == RouteGroqChat {
+		provider = nil
+	}
+
+	if !scenario.Stream && !req.Stream {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"id":       id,
+			"object":   "chat.completion",
+			"created":  time.Now().Unix(),
+			"model":    req.Model,
+			"provider": provider,
+			"choices": []map[string]any{{
+				"index":         0,
+				"finish_reason": finishReason,
+				"message": map[string]any{
+					"role":       t.RoleAssistant,
+					"content":    content,
+					"tool_calls": scenario.ToolCalls,
+				},
+			}},
+			"usage": map[string]any{
+				"prompt_tokens":     promptTokens,
+				"completion_tokens": completionTokens,
+				"total_tokens":      promptTokens + completionTokens,
+			},
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	event := func(delta map[string]any, finish any) {
+		data, _ := json.Marshal(map[string]any{
+			"id":       id,
+			"object":   "chat.completion.chunk",
+			"model":    req.Model,
+			"provider": provider,
+			"choices":  []map[string]any{{"index": 0, "delta": delta, "finish_reason": finish}},
+		})
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	for i, word := range strings.SplitAfter(content, " ") {
+		if i > 0 && scenario.ChunkDelay > 0 {
+			select {
+			case <-time.After(scenario.ChunkDelay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		event(map[string]any{"role": t.RoleAssistant, "content": word}, nil)
+	}
+	if scenario.ToolCalls != nil {
+		event(map[string]any{"role": t.RoleAssistant, "tool_calls": scenario.ToolCalls}, nil)
+	}
+	event(map[string]any{}, finishReason)
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+// messageText returns the text of a message's content, which is a string or a list of parts.
+func messageText(content any) string {
+	switch content := content.(type) {
+	case string:
+		return content
+	case []any:
+		var texts []string
+		for _, part := range content {
+			if part, ok := part.(map[string]any); ok {
+				if text, ok := part["text"].(string); ok {
+					texts = append(texts, text)
+				}
+			}
+		}
+		return strings.Join(texts, "\n")
+	default:
+		return ""
+	}
+}
+
+func transcriptionBody(r *http.Request, scenario Scenario, task string) map[string]any {
+	text := scenario.Text
+	duration := 0.0
+	var granularities []string
+	if err := r.ParseMultipartForm(32 << 20); err == nil {
+		if file, header, err := r.FormFile("file"); err == nil {
+			file.Close()
+			// 16kHz mono 16-bit WAV, as sent by the client after splitting
+			duration = float64(max(0, header.Size-44)) / 32000
+		}
+		granularities = r.MultipartForm.Value["timestamp_granularities[]"]
+	}
+	if text == "" {
+		text = " This is a mock transcription."
+	}
+
+	body := map[string]any{
+		"task":     task,
+		"language": "English",
+		"duration": duration,
+		"text":     text,
+		"segments": []map[string]any{{
+			"id":                0,
+			"seek":              0,
+			"start":             0,
+			"end":               duration,
+			"text":              text,
+			"temperature":       0,
+			"avg_logprob":       -0.2,
+			"compression_ratio": 1.2,
+			"no_speech_prob":    0.01,
+		}},
+	}
+	if slices.Contains(granularities, "word") {
+		// Spread the words evenly over the audio
+		fields := strings.Fields(text)
+		words := make([]map[string]any, 0, len(fields))
+		for i, word := range fields {
+			words = append(words, map[string]any{
+				"word":  word,
+				"start": duration * float64(i) / float64(len(fields)),
+				"end":   duration * float64(i+1) / float64(len(fields)),
+			})
+		}
+		body["words"] = words
+	}
+	return body
+}
+
+func modelsBody(models []string) map[string]any {
+	data := make([]map[string]any, 0, len(models))
+	for _, model := range models {
+		data = append(data, map[string]any{
+			"id":             model,
+			"name":           model,
+			"context_length": 131072,
+			"pricing":        map[string]string{"prompt": "0", "completion": "0"},
+		})
+	}
+	return map[string]any{"data": data}
+}
+
+func generationBody(id string) map[string]any {
+	return map[string]any{
+		"data": map[string]any{
+			"id":                       id,
+			"total_cost":               0,
+			"provider_name":            "Mock",
+			"tokens_prompt":            0,
+			"tokens_completion":        0,
+			"generation_time":          0,
+			"native_finish_reason":     "stop",
+			"cancelled":                false,
+			"native_tokens_prompt":     0,
+			"native_tokens_completion": 0,
+		},
+	}
+}
+
+func errorBody(statusCode int) map[string]any {
+	return map[string]any{
+		"error": map[string]any{
+			"code":     statusCode,
+			"message":  http.StatusText(statusCode),
+			"metadata": map[string]any{"provider_name": "Mock"},
+		},
+	}
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body any) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(data)
+}
@@ -0,0 +1,271 @@
+// Package otelllm instruments the clients with OpenTelemetry traces and metrics
+// following the GenAI semantic conventions.
+//
+// Add Middleware and AttemptMiddleware to a client config to get a span for every call
+// with a child span for every HTTP request, including retries, fallbacks and hedged requests.
+package otelllm
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptrace"
+	"net/url"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/Floris22/go-llm/v2/clients"
+	t "github.com/Floris22/go-llm/v2/llmtypes"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/Floris22/go-llm/v2/otelllm"
+
+// Attributes not covered by the semantic conventions
+const (
+	// Upstream provider OpenRouter routed the call to, e.g. "Together"
+	OpenRouterProviderKey = attribute.Key("openrouter.provider")
+
+	// Whether the response was served from the response cache
+	CacheHitKey = attribute.Key("go_llm.cache_hit")
+
+	// Kind and position of an HTTP request within its call
+	AttemptKindKey   = attribute.Key("go_llm.attempt.kind")
+	AttemptNumberKey = attribute.Key("go_llm.attempt.number")
+
+	// Duration of the transcribed audio in seconds
+	AudioDurationKey = attribute.Key("go_llm.audio.duration")
+)
+
+// Config holds the providers used for instrumentation. Nil values use the global providers.
+type Config struct {
+	TracerProvider trace.TracerProvider
+	MeterProvider  metric.MeterProvider
+}
+
+// Instrumentation creates the middleware recording spans and metrics.
+// One Instrumentation can be shared by multiple clients.
+type Instrumentation struct {
+	tracer           trace.Tracer
+	duration         metric.Float64Histogram
+	timeToFirstChunk metric.Float64Histogram
+	tokenUsage       metric.Int64Histogram
+}
+
+func New(config Config) (*Instrumentation, error) {
+	if config.TracerProvider == nil {
+		config.TracerProvider = otel.GetTracerProvider()
+	}
+	if config.MeterProvider == nil {
+		config.MeterProvider = otel.GetMeterProvider()
+	}
+	meter := config.MeterProvider.Meter(instrumentationName)
+
+	duration, err := meter.Float64Histogram(
+		"gen_ai.client.operation.duration",
+		metric.WithDescription("GenAI operation duration."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(
+			0.01, 0.02, 0.04, 0.08, 0.16, 0.32, 0.64, 1.28, 2.56, 5.12, 10.24, 20.48, 40.96, 81.92,
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	timeToFirstChunk, err := meter.Float64Histogram(
+		"gen_ai.client.operation.time_to_first_chunk",
+		metric.WithDescription("Time from sending a request until the first byte of its response."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(
+			0.01, 0.02, 0.04, 0.08, 0.16, 0.32, 0.64, 1.28, 2.56, 5.12, 10.24, 20.48, 40.96, 81.92,
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenUsage, err := meter.Int64Histogram(
+		"gen_ai.client.token.usage",
+		metric.WithDescription("Number of input and output tokens used."),
+		metric.WithUnit("{token}"),
+		metric.WithExplicitBucketBoundaries(
+			1, 4, 16, 64, 256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216, 67108864,
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Instrumentation{
+		tracer:           config.TracerProvider.Tracer(instrumentationName),
+		duration:         duration,
+		timeToFirstChunk: timeToFirstChunk,
+		tokenUsage:       tokenUsage,
+	}, nil
+}
+
+// Middleware returns the call middleware, it records a span, the operation duration and
+// the token usage of every call. Add it first so it also covers other middleware.
+func (i *Instrumentation) Middleware() clients.Middleware {
+	return func(next clients.Handler) clients.Handler {
+		return func(ctx context.Context, call *clients.Call) (*clients.Result, error) {
+			operation := operationName(call.Operation)
+			attrs := []attribute.KeyValue{
+				operation,
+				semconv.GenAIProviderNameKey.String(string(call.System)),
+				semconv.GenAIRequestModel(call.Model),
+			}
+
+			spanAttrs := slices.Clip(attrs)
+			if call.Temperature != nil {
+				spanAttrs = append(spanAttrs, semconv.GenAIRequestTemperature(*call.Temperature))
+			}
+			if call.MaxTokens != nil {
+				spanAttrs = append(spanAttrs, semconv.GenAIRequestMaxTokens(*call.MaxTokens))
+			}
+
+			ctx, span := i.tracer.Start(
+				ctx,
+				fmt.Sprintf("%s %s", operation.Value.AsString(), call.Model),
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(spanAttrs...),
+			)
+			defer span.End()
+
+			start := time.Now()
+			result, err := next(ctx, call)
+			elapsed := time.Since(start).Seconds()
+
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				attrs = append(attrs, semconv.ErrorType(err))
+			}
+
+			if result != nil && err == nil {
+				switch call.Operation {
+				case clients.OperationTranscribe, clients.OperationTranslate:
+					span.SetAttributes(AudioDurationKey.Float64(result.Transcription.Duration))
+				default:
+					attrs = append(attrs, semconv.GenAIResponseModel(result.Response.Model))
+					i.recordResponse(ctx, span, attrs, result.Response)
+				}
+			}
+
+			i.duration.Record(ctx, elapsed, metric.WithAttributes(attrs...))
+			return result, err
+		}
+	}
+}
+
+// recordResponse sets the response attributes on span and records its token usage.
+// Cached responses don't use tokens.
+func (i *Instrumentation) recordResponse(
+	ctx context.Context,
+	span trace.Span,
+	attrs []attribute.KeyValue,
+	response t.OpenRouterResponse,
+) {
+	var finishReasons []string
+	for _, choice := range response.Choices {
+		finishReasons = append(finishReasons, choice.FinishReason)
+	}
+	span.SetAttributes(
+		semconv.GenAIResponseID(response.ID),
+		semconv.GenAIResponseModel(response.Model),
+		semconv.GenAIResponseFinishReasons(finishReasons...),
+		semconv.GenAIUsageInputTokens(response.Usage.PromptTokens),
+		semconv.GenAIUsageOutputTokens(response.Usage.CompletionTokens),
+		semconv.GenAIUsageCacheReadInputTokens(response.Usage.PromptTokensDetails.CachedTokens),
+	)
+	if response.Provider != "" {
+		span.SetAttributes(OpenRouterProviderKey.String(response.Provider))
+	}
+	if response.CacheHit {
+		span.SetAttributes(CacheHitKey.Bool(true))
+		return
+	}
+
+	i.tokenUsage.Record(
+		ctx,
+		int64(response.Usage.PromptTokens),
+		metric.WithAttributes(slices.Concat(attrs, []attribute.KeyValue{semconv.GenAITokenTypeInput})...),
+	)
+	i.tokenUsage.Record(
+		ctx,
+		int64(response.Usage.CompletionTokens),
+		metric.WithAttributes(slices.Concat(attrs, []attribute.KeyValue{semconv.GenAITokenTypeOutput})...),
+	)
+}
+
+// AttemptMiddleware returns the attempt middleware, it records a child span of the call
+// and the time to the first response byte for every HTTP request.
+func (i *Instrumentation) AttemptMiddleware() clients.AttemptMiddleware {
+	return func(next clients.AttemptHandler) clients.AttemptHandler {
+		return func(ctx context.Context, attempt *clients.Attempt) (*clients.AttemptResponse, error) {
+			spanAttrs := []attribute.KeyValue{
+				operationName(attempt.Operation),
+				semconv.GenAIRequestModel(attempt.Model),
+				AttemptKindKey.String(string(attempt.Kind)),
+				AttemptNumberKey.Int(attempt.Number),
+				semconv.URLFull(attempt.URL),
+			}
+			if u, err := url.Parse(attempt.URL); err == nil {
+				spanAttrs = append(spanAttrs, semconv.ServerAddress(u.Hostname()))
+			}
+
+			ctx, span := i.tracer.Start(
+				ctx,
+				fmt.Sprintf("%s %s", attempt.Kind, attempt.Model),
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(spanAttrs...),
+			)
+			defer span.End()
+
+			start := time.Now()
+			var firstByte atomic.Int64
+			ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+				GotFirstResponseByte: func() {
+					firstByte.Store(int64(time.Since(start)))
+				},
+			})
+
+			resp, err := next(ctx, attempt)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return resp, err
+			}
+
+			span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+			if resp.StatusCode >= 400 {
+				span.SetStatus(codes.Error, fmt.Sprintf("status code %d", resp.StatusCode))
+			} else if elapsed := firstByte.Load(); elapsed > 0 {
+				i.timeToFirstChunk.Record(
+					ctx,
+					time.Duration(elapsed).Seconds(),
+					metric.WithAttributes(operationName(attempt.Operation), semconv.GenAIRequestModel(attempt.Model)),
+				)
+			}
+			return resp, err
+		}
+	}
+}
+
+// operationName maps a client operation to gen_ai.operation.name.
+func operationName(operation clients.OperationEnum) attribute.KeyValue {
+	switch operation {
+	case clients.OperationTranscribe:
+		return semconv.GenAIOperationNameKey.String("transcription")
+	case clients.OperationTranslate:
+		return semconv.GenAIOperationNameKey.String("translation")
+	default:
+		return semconv.GenAIOperationNameChat
+	}
+}
@@ -0,0 +1,225 @@
+package otelllm_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Floris22/go-llm/v2/clients"
+	"github.com/Floris22/go-llm/v2/llmtypes"
+	"github.com/Floris22/go-llm/v2/mockserver"
+	"github.com/Floris22/go-llm/v2/otelllm"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type recorded struct {
+	spans   *tracetest.SpanRecorder
+	metrics *sdkmetric.ManualReader
+}
+
+func newInstrumentation(t *testing.T) (*otelllm.Instrumentation, recorded) {
+	spans := tracetest.NewSpanRecorder()
+	metrics := sdkmetric.NewManualReader()
+	instrumentation, err := otelllm.New(otelllm.Config{
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+		MeterProvider:  sdkmetric.NewMeterProvider(sdkmetric.WithReader(metrics)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return instrumentation, recorded{spans: spans, metrics: metrics}
+}
+
+func newClient(server *mockserver.Server, instrumentation *otelllm.Instrumentation) clients.OpenRouterClient {
+	return clients.NewOpenRouterClientWithConfig("key", clients.OpenRouterConfig{
+		Model:             "test/model",
+		BaseURL:           server.OpenRouterURL(),
+		Middleware:        []clients.Middleware{instrumentation.Middleware()},
+		AttemptMiddleware: []clients.AttemptMiddleware{instrumentation.AttemptMiddleware()},
+	})
+}
+
+func generate(client clients.OpenRouterClient) error {
+	content := "hello"
+	messages := []llmtypes.MessageForLLM{{Role: llmtypes.RoleUser, Content: &content}}
+	_, err := client.GenerateText(messages, nil, "", nil, nil, nil, nil, nil, nil)
+	return err
+}
+
+func attrs(kvs []attribute.KeyValue) map[attribute.Key]attribute.Value {
+	m := map[attribute.Key]attribute.Value{}
+	for _, kv := range kvs {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func checkAttrs(t *testing.T, what string, got map[attribute.Key]attribute.Value, want map[attribute.Key]any) {
+	t.Helper()
+	for key, value := range want {
+		v, ok := got[key]
+		if !ok {
+			t.Errorf("%s: missing attribute %s", what, key)
+			continue
+		}
+		if v.AsInterface() != value {
+			t.Errorf("%s: %s = %v, want %v", what, key, v.AsInterface(), value)
+		}
+	}
+}
+
+func collect(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Aggregation {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	metrics := map[string]metricdata.Aggregation{}
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	return metrics
+}
+
+func TestChatSpansAndMetrics(t *testing.T) {
+	server := mockserver.New()
+	defer server.Close()
+	instrumentation, rec := newInstrumentation(t)
+
+	if err := generate(newClient(server, instrumentation)); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := rec.spans.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want a call and an attempt span", len(spans))
+	}
+	attempt, call := spans[0], spans[1]
+
+	if call.Name() != "chat test/model" || call.SpanKind() != trace.SpanKindClient {
+		t.Errorf("call span = %q kind %v, want \"chat test/model\" kind client", call.Name(), call.SpanKind())
+	}
+	checkAttrs(t, "call span", attrs(call.Attributes()), map[attribute.Key]any{
+		"gen_ai.operation.name":      "chat",
+		"gen_ai.provider.name":       "openrouter",
+		"gen_ai.request.model":       "test/model",
+		"gen_ai.response.model":      "test/model",
+		"openrouter.provider":        "Mock",
+		"gen_ai.usage.output_tokens": int64(6),
+	})
+	if _, ok := attrs(call.Attributes())["gen_ai.usage.input_tokens"]; !ok {
+		t.Error("call span: missing gen_ai.usage.input_tokens")
+	}
+
+	if attempt.Name() != "primary test/model" || attempt.Parent().SpanID() != call.SpanContext().SpanID() {
+		t.Errorf("attempt span = %q, want \"primary test/model\" as a child of the call span", attempt.Name())
+	}
+	checkAttrs(t, "attempt span", attrs(attempt.Attributes()), map[attribute.Key]any{
+		"gen_ai.operation.name":     "chat",
+		"go_llm.attempt.kind":       "primary",
+		"go_llm.attempt.number":     int64(1),
+		"http.response.status_code": int64(200),
+		"server.address":            "127.0.0.1",
+	})
+
+	metrics := collect(t, rec.metrics)
+	duration, ok := metrics["gen_ai.client.operation.duration"].(metricdata.Histogram[float64])
+	if !ok || len(duration.DataPoints) != 1 || duration.DataPoints[0].Count != 1 {
+		t.Fatalf("gen_ai.client.operation.duration = %+v, want one data point", metrics["gen_ai.client.operation.duration"])
+	}
+	checkAttrs(t, "duration metric", attrs(duration.DataPoints[0].Attributes.ToSlice()), map[attribute.Key]any{
+		"gen_ai.operation.name": "chat",
+		"gen_ai.provider.name":  "openrouter",
+		"gen_ai.request.model":  "test/model",
+		"gen_ai.response.model": "test/model",
+	})
+
+	usage, ok := metrics["gen_ai.client.token.usage"].(metricdata.Histogram[int64])
+	if !ok || len(usage.DataPoints) != 2 {
+		t.Fatalf("gen_ai.client.token.usage = %+v, want input and output data points", metrics["gen_ai.client.token.usage"])
+	}
+	tokenTypes := map[string]bool{}
+	for _, point := range usage.DataPoints {
+		value, _ := point.Attributes.Value("gen_ai.token.type")
+		tokenTypes[value.AsString()] = true
+	}
+	if !tokenTypes["input"] || !tokenTypes["output"] {
+		t.Errorf("token types = %v, want input and output", tokenTypes)
+	}
+
+	if _, ok := metrics["gen_ai.client.operation.time_to_first_chunk"].(metricdata.Histogram[float64]); !ok {
+		t.Error("gen_ai.client.operation.time_to_first_chunk not recorded")
+	}
+}
+
+func TestErrorSpansAndMetrics(t *testing.T) {
+	server := mockserver.New()
+	defer server.Close()
+	server.Enqueue(mockserver.RouteChatCompletions, mockserver.Scenario{StatusCode: 400})
+	instrumentation, rec := newInstrumentation(t)
+
+	if err := generate(newClient(server, instrumentation)); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	spans := rec.spans.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want a call and an attempt span", len(spans))
+	}
+	attempt, call := spans[0], spans[1]
+	if call.Status().Code != codes.Error || len(call.Events()) == 0 || call.Events()[0].Name != "exception" {
+		t.Errorf("call span status = %v events = %v, want an error with an exception event", call.Status(), call.Events())
+	}
+	if attempt.Status().Code != codes.Error {
+		t.Errorf("attempt span status = %v, want an error", attempt.Status())
+	}
+	checkAttrs(t, "attempt span", attrs(attempt.Attributes()), map[attribute.Key]any{
+		"http.response.status_code": int64(400),
+	})
+
+	metrics := collect(t, rec.metrics)
+	duration := metrics["gen_ai.client.operation.duration"].(metricdata.Histogram[float64])
+	checkAttrs(t, "duration metric", attrs(duration.DataPoints[0].Attributes.ToSlice()), map[attribute.Key]any{
+		"error.type": "*llmtypes.APIError",
+	})
+	if _, ok := metrics["gen_ai.client.token.usage"]; ok {
+		t.Error("token usage recorded for a failed call")
+	}
+	if _, ok := metrics["gen_ai.client.operation.time_to_first_chunk"]; ok {
+		t.Error("time to first chunk recorded for a failed request")
+	}
+}
+
+func TestTranscriptionSpan(t *testing.T) {
+	server := mockserver.New()
+	defer server.Close()
+	instrumentation, rec := newInstrumentation(t)
+	client := clients.NewGroqClientWithConfig("key", clients.GroqConfig{
+		BaseURL:    server.GroqURL(),
+		Middleware: []clients.Middleware{instrumentation.Middleware()},
+	})
+
+	audioURL := "https://example.com/audio.mp3"
+	if _, err := client.Transcribe("whisper", "", &audioURL, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := rec.spans.Ended()
+	if len(spans) != 1 || spans[0].Name() != "transcription whisper" {
+		t.Fatalf("spans = %v, want one \"transcription whisper\" span", spans)
+	}
+	checkAttrs(t, "call span", attrs(spans[0].Attributes()), map[attribute.Key]any{
+		"gen_ai.operation.name": "transcription",
+		"gen_ai.provider.name":  "groq",
+	})
+	if _, ok := attrs(spans[0].Attributes())["go_llm.audio.duration"]; !ok {
+		t.Error("call span: missing go_llm.audio.duration")
+	}
+}
@@ -0,0 +1,286 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ScopeEnum string
+
+const (
+	// One set of buckets shared by all requests
+	ScopeGlobal ScopeEnum = "global"
+
+	// Separate buckets per model
+	ScopeModel ScopeEnum = "model"
+
+	// Separate buckets per API key
+	ScopeKey ScopeEnum = "key"
+
+	// Separate buckets per API key and model
+	ScopeKeyAndModel ScopeEnum = "key_and_model"
+)
+
+// Config defines the buckets of a BucketLimiter. Zero values disable a bucket.
+type Config struct {
+	RequestsPerMinute   int
+	TokensPerMinute     int
+	AudioSecondsPerHour float64
+
+	// How requests are grouped into buckets, defaults to ScopeGlobal
+	Scope ScopeEnum
+
+	// Return a *LimitError instead of waiting when a bucket is exhausted
+	FailFast bool
+}
+
+// BucketLimiter is a token bucket Limiter with request, token and audio buckets.
+// Buckets refill continuously and start full.
+// It pauses a scope when a response reports no remaining requests or tokens,
+// or returns a Retry-After header.
+type BucketLimiter struct {
+	config Config
+
+	mu     sync.Mutex
+	scopes map[string]*scope
+	now    func() time.Time
+}
+
+type scope struct {
+	requests    *bucket
+	tokens      *bucket
+	audio       *bucket
+	pausedUntil time.Time
+	pausedBy    string
+}
+
+type bucket struct {
+	name     string
+	capacity float64
+	perSec   float64
+	level    float64
+	updated  time.Time
+}
+
+// New creates a BucketLimiter.
+func New(config Config) *BucketLimiter {
+	if config.Scope == "" {
+		config.Scope = ScopeGlobal
+	}
+	return &BucketLimiter{
+		config: config,
+		scopes: map[string]*scope{},
+		now:    time.Now,
+	}
+}
+
+func (l *BucketLimiter) Wait(ctx context.Context, req Request) error {
+	key := l.key(req)
+	for {
+		l.mu.Lock()
+		s := l.scope(key)
+		wait, exhausted := l.reserve(s, req)
+		l.mu.Unlock()
+		if wait <= 0 {
+			return nil
+		}
+
+		if l.config.FailFast {
+			return &LimitError{Bucket: exhausted, Key: key, RetryAfter: wait}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return context.Cause(ctx)
+		}
+	}
+}
+
+func (l *BucketLimiter) Observe(req Request, obs Observation) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s := l.scope(l.key(req))
+	now := l.now()
+
+	// Charge the difference between the estimate and the real usage
+	if s.tokens != nil && obs.Tokens > 0 {
+		s.tokens.refill(now)
+		s.tokens.level -= float64(obs.Tokens - min(req.Tokens, int(s.tokens.capacity)))
+	}
+	if s.audio != nil && obs.AudioSeconds > 0 {
+		s.audio.refill(now)
+		s.audio.level -= obs.AudioSeconds - math.Min(req.AudioSeconds, s.audio.capacity)
+	}
+
+	if obs.Header == nil {
+		return
+	}
+
+	if retryAfter, ok := parseReset(obs.Header.Get("Retry-After"), now); ok && obs.StatusCode == http.StatusTooManyRequests {
+		s.pause(now.Add(retryAfter), "retry_after")
+	}
+
+	// Groq style headers
+	l.adapt(s, s.requests, "requests", obs.Header, "x-ratelimit-remaining-requests", "x-ratelimit-reset-requests", now)
+	l.adapt(s, s.tokens, "tokens", obs.Header, "x-ratelimit-remaining-tokens", "x-ratelimit-reset-tokens", now)
+
+	// OpenRouter style headers
+	l.adapt(s, s.requests, "requests", obs.Header, "x-ratelimit-remaining", "x-ratelimit-reset", now)
+}
+
+// adapt lowers b to the remaining amount reported by the API,
+// and pauses s until the reset if nothing remains.
+func (l *BucketLimiter) adapt(s *scope, b *bucket, name string, header http.Header, remainingKey string, resetKey string, now time.Time) {
+	remainingValue := header.Get(remainingKey)
+	if remainingValue == "" {
+		return
+	}
+	remaining, err := strconv.ParseFloat(remainingValue, 64)
+	if err != nil {
+		return
+	}
+
+	if b != nil {
+		b.refill(now)
+		b.level = math.Min(b.level, remaining)
+	}
+	if remaining <= 0 {
+		if reset, ok := parseReset(header.Get(resetKey), now); ok {
+			s.pause(now.Add(reset), name)
+		}
+	}
+}
+
+// reserve takes the cost of req from all buckets of s if they allow it.
+// Otherwise it returns how long to wait and the name of the exhausted bucket.
+func (l *BucketLimiter) reserve(s *scope, req Request) (time.Duration, string) {
+	now := l.now()
+	if wait := s.pausedUntil.Sub(now); wait > 0 {
+		return wait, s.pausedBy
+	}
+
+	costs := []struct {
+		b    *bucket
+		cost float64
+	}{
+		{s.requests, 1},
+		{s.tokens, float64(req.Tokens)},
+		{s.audio, req.AudioSeconds},
+	}
+
+	var wait time.Duration
+	var exhausted string
+	for _, c := range costs {
+		if c.b == nil || c.cost <= 0 {
+			continue
+		}
+		c.b.refill(now)
+		if w := c.b.waitFor(math.Min(c.cost, c.b.capacity)); w > wait {
+			wait, exhausted = w, c.b.name
+		}
+	}
+	if wait > 0 {
+		return wait, exhausted
+	}
+
+	for _, c := range costs {
+		if c.b != nil && c.cost > 0 {
+			c.b.level -= math.Min(c.cost, c.b.capacity)
+		}
+	}
+	return 0, ""
+}
+
+func (l *BucketLimiter) key(req Request) string {
+	switch l.config.Scope {
+	case ScopeModel:
+		return req.Model
+	case ScopeKey:
+		return req.APIKey
+	case ScopeKeyAndModel:
+		return req.APIKey + "/" + req.Model
+	default:
+		return ""
+	}
+}
+
+func (l *BucketLimiter) scope(key string) *scope {
+	s, ok := l.scopes[key]
+	if ok {
+		return s
+	}
+
+	now := l.now()
+	s = &scope{
+		requests: newBucket("requests", float64(l.config.RequestsPerMinute), time.Minute, now),
+		tokens:   newBucket("tokens", float64(l.config.TokensPerMinute), time.Minute, now),
+		audio:    newBucket("audio_seconds", l.config.AudioSecondsPerHour, time.Hour, now),
+	}
+	l.scopes[key] = s
+	return s
+}
+
+func (s *scope) pause(until time.Time, by string) {
+	if until.After(s.pausedUntil) {
+		s.pausedUntil = until
+		s.pausedBy = by
+	}
+}
+
+// newBucket returns nil if capacity is zero, disabling the bucket.
+func newBucket(name string, capacity float64, per time.Duration, now time.Time) *bucket {
+	if capacity <= 0 {
+		return nil
+	}
+	return &bucket{
+		name:     name,
+		capacity: capacity,
+		perSec:   capacity / per.Seconds(),
+		level:    capacity,
+		updated:  now,
+	}
+}
+
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed > 0 {
+		b.level = math.Min(b.capacity, b.level+elapsed*b.perSec)
+		b.updated = now
+	}
+}
+
+func (b *bucket) waitFor(cost float64) time.Duration {
+	if b.level >= cost {
+		return 0
+	}
+	return time.Duration((cost - b.level) / b.perSec * float64(time.Second))
+}
+
+// parseReset parses a reset header value. Supports durations ("2m59.56s"),
+// seconds ("30") and unix timestamps in milliseconds.
+func parseReset(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return d, true
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+	if n > 1e12 {
+		return time.UnixMilli(int64(n)).Sub(now), true
+	}
+	return time.Duration(n * float64(time.Second)), true
+}
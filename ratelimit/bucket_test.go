@@ -0,0 +1,313 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock that only moves when advanced.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestLimiter(config Config) (*BucketLimiter, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	config.FailFast = true
+	limiter := New(config)
+	limiter.now = clock.Now
+	return limiter, clock
+}
+
+// step advances the clock and waits for req, expecting it to be allowed
+// or to fail with wantBucket after wantRetry.
+type step struct {
+	advance    time.Duration
+	req        Request
+	times      int
+	wantBucket string
+	wantRetry  time.Duration
+}
+
+func runSteps(t *testing.T, limiter *BucketLimiter, clock *fakeClock, steps []step) {
+	t.Helper()
+	for i, step := range steps {
+		clock.now = clock.now.Add(step.advance)
+		for range max(1, step.times) {
+			if err := limiter.Wait(context.Background(), step.req); err != nil {
+				t.Fatalf("step %d: unexpected error: %v", i, err)
+			}
+		}
+		if step.wantBucket == "" {
+			continue
+		}
+
+		err := limiter.Wait(context.Background(), step.req)
+		var limitErr *LimitError
+		if !errors.As(err, &limitErr) || !errors.Is(err, ErrRateLimited) {
+			t.Fatalf("step %d: error = %v, want a *LimitError", i, err)
+		}
+		if limitErr.Bucket != step.wantBucket || limitErr.RetryAfter != step.wantRetry {
+			t.Errorf("step %d: %s exhausted for %s, want %s for %s", i, limitErr.Bucket, limitErr.RetryAfter, step.wantBucket, step.wantRetry)
+		}
+	}
+}
+
+func TestBucketRefill(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		steps  []step
+	}{
+		{
+			name:   "requests",
+			config: Config{RequestsPerMinute: 60},
+			steps: []step{
+				{times: 60, wantBucket: "requests", wantRetry: time.Second},
+				{advance: time.Second, wantBucket: "requests", wantRetry: time.Second},
+				{advance: 30 * time.Second, times: 30, wantBucket: "requests", wantRetry: time.Second},
+				// Buckets don't fill beyond their capacity
+				{advance: time.Hour, times: 60, wantBucket: "requests", wantRetry: time.Second},
+			},
+		},
+		{
+			name:   "tokens",
+			config: Config{TokensPerMinute: 1200},
+			steps: []step{
+				{req: Request{Tokens: 800}, wantBucket: "tokens", wantRetry: 20 * time.Second},
+				{advance: 20 * time.Second, req: Request{Tokens: 800}, wantBucket: "tokens", wantRetry: 40 * time.Second},
+				// Requests larger than the bucket wait for a full bucket instead of forever
+				{advance: time.Minute, req: Request{Tokens: 5000}, wantBucket: "tokens", wantRetry: time.Minute},
+			},
+		},
+		{
+			name:   "audio seconds",
+			config: Config{AudioSecondsPerHour: 3600},
+			steps: []step{
+				{req: Request{AudioSeconds: 3000}, wantBucket: "audio_seconds", wantRetry: 2400 * time.Second},
+			},
+		},
+		{
+			name:   "longest wait of several buckets",
+			config: Config{RequestsPerMinute: 60, TokensPerMinute: 600},
+			steps: []step{
+				{req: Request{Tokens: 600}, wantBucket: "tokens", wantRetry: time.Minute},
+				{advance: time.Minute, req: Request{Tokens: 1}, times: 60, wantBucket: "requests", wantRetry: time.Second},
+			},
+		},
+		{
+			name:   "disabled buckets",
+			config: Config{},
+			steps: []step{
+				{req: Request{Tokens: 1e6, AudioSeconds: 1e6}, times: 100},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			limiter, clock := newTestLimiter(test.config)
+			runSteps(t, limiter, clock, test.steps)
+		})
+	}
+}
+
+func TestScopeKeys(t *testing.T) {
+	a := Request{APIKey: "key-1", Model: "model-a"}
+	tests := []struct {
+		scope       ScopeEnum
+		wantKey     string
+		wantLimited []Request
+		wantAllowed []Request
+	}{
+		{
+			scope:       ScopeGlobal,
+			wantKey:     "",
+			wantLimited: []Request{{APIKey: "key-2", Model: "model-b"}},
+		},
+		{
+			scope:       ScopeModel,
+			wantKey:     "model-a",
+			wantLimited: []Request{{APIKey: "key-2", Model: "model-a"}},
+			wantAllowed: []Request{{APIKey: "key-1", Model: "model-b"}},
+		},
+		{
+			scope:       ScopeKey,
+			wantKey:     "key-1",
+			wantLimited: []Request{{APIKey: "key-1", Model: "model-b"}},
+			wantAllowed: []Request{{APIKey: "key-2", Model: "model-a"}},
+		},
+		{
+			scope:       ScopeKeyAndModel,
+			wantKey:     "key-1/model-a",
+			wantAllowed: []Request{{APIKey: "key-1", Model: "model-b"}, {APIKey: "key-2", Model: "model-a"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.scope), func(t *testing.T) {
+			limiter, _ := newTestLimiter(Config{RequestsPerMinute: 1, Scope: test.scope})
+			if err := limiter.Wait(context.Background(), a); err != nil {
+				t.Fatal(err)
+			}
+
+			var limitErr *LimitError
+			if err := limiter.Wait(context.Background(), a); !errors.As(err, &limitErr) || limitErr.Key != test.wantKey {
+				t.Errorf("error = %v, want a *LimitError for key %q", err, test.wantKey)
+			}
+			for _, req := range test.wantLimited {
+				if err := limiter.Wait(context.Background(), req); !errors.Is(err, ErrRateLimited) {
+					t.Errorf("%+v: error = %v, want it to share the exhausted bucket", req, err)
+				}
+			}
+			for _, req := range test.wantAllowed {
+				if err := limiter.Wait(context.Background(), req); err != nil {
+					t.Errorf("%+v: error = %v, want its own bucket", req, err)
+				}
+			}
+		})
+	}
+}
+
+func TestWaitBlocksUntilContextDone(t *testing.T) {
+	limiter := New(Config{RequestsPerMinute: 1})
+	if err := limiter.Wait(context.Background(), Request{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := limiter.Wait(ctx, Request{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want the context's error", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("returned after %s, want it to block until the context is done", elapsed)
+	}
+}
+
+func TestObserve(t *testing.T) {
+	header := func(kv ...string) http.Header {
+		h := http.Header{}
+		for i := 0; i < len(kv); i += 2 {
+			h.Set(kv[i], kv[i+1])
+		}
+		return h
+	}
+	start := time.Unix(1_700_000_000, 0)
+
+	tests := []struct {
+		name       string
+		config     Config
+		req        Request
+		obs        Observation
+		wantBucket string
+		wantRetry  time.Duration
+	}{
+		{
+			name:       "retry after on 429",
+			config:     Config{RequestsPerMinute: 100},
+			obs:        Observation{StatusCode: 429, Header: header("Retry-After", "2")},
+			wantBucket: "retry_after",
+			wantRetry:  2 * time.Second,
+		},
+		{
+			name:   "retry after ignored on success",
+			config: Config{RequestsPerMinute: 100},
+			obs:    Observation{StatusCode: 200, Header: header("Retry-After", "2")},
+		},
+		{
+			name:   "groq remaining requests",
+			config: Config{RequestsPerMinute: 100},
+			obs: Observation{StatusCode: 200, Header: header(
+				"x-ratelimit-remaining-requests", "0", "x-ratelimit-reset-requests", "1m30s",
+			)},
+			wantBucket: "requests",
+			wantRetry:  90 * time.Second,
+		},
+		{
+			name:   "groq remaining tokens lower the bucket",
+			config: Config{TokensPerMinute: 6000},
+			req:    Request{Tokens: 200},
+			obs: Observation{StatusCode: 200, Header: header(
+				"x-ratelimit-remaining-tokens", "100", "x-ratelimit-reset-tokens", "7.66s",
+			)},
+			wantBucket: "tokens",
+			wantRetry:  time.Second,
+		},
+		{
+			name:   "groq tokens exhausted without a bucket",
+			config: Config{RequestsPerMinute: 100},
+			obs: Observation{StatusCode: 200, Header: header(
+				"x-ratelimit-remaining-tokens", "0", "x-ratelimit-reset-tokens", "7.5s",
+			)},
+			wantBucket: "tokens",
+			wantRetry:  7500 * time.Millisecond,
+		},
+		{
+			name:   "openrouter reset timestamp",
+			config: Config{RequestsPerMinute: 100},
+			obs: Observation{StatusCode: 200, Header: header(
+				"x-ratelimit-remaining", "0", "x-ratelimit-reset", strconv.FormatInt(start.Add(45*time.Second).UnixMilli(), 10),
+			)},
+			wantBucket: "requests",
+			wantRetry:  45 * time.Second,
+		},
+		{
+			name:       "real token usage charged",
+			config:     Config{TokensPerMinute: 600},
+			req:        Request{Tokens: 100},
+			obs:        Observation{StatusCode: 200, Tokens: 600},
+			wantBucket: "tokens",
+			wantRetry:  10 * time.Second,
+		},
+		{
+			name:       "real audio usage charged",
+			config:     Config{AudioSecondsPerHour: 3600},
+			obs:        Observation{StatusCode: 200, AudioSeconds: 3600},
+			wantBucket: "audio_seconds",
+			wantRetry:  time.Second,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			limiter, clock := newTestLimiter(test.config)
+			clock.now = start
+			if err := limiter.Wait(context.Background(), test.req); err != nil {
+				t.Fatal(err)
+			}
+			limiter.Observe(test.req, test.obs)
+
+			next := test.req
+			if next.Tokens == 0 && test.config.TokensPerMinute > 0 {
+				next.Tokens = 1
+			}
+			if next.AudioSeconds == 0 && test.config.AudioSecondsPerHour > 0 {
+				next.AudioSeconds = 1
+			}
+			err := limiter.Wait(context.Background(), next)
+			if test.wantBucket == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("error = %v, want a *LimitError", err)
+			}
+			if limitErr.Bucket != test.wantBucket || limitErr.RetryAfter != test.wantRetry {
+				t.Errorf("%s exhausted for %s, want %s for %s", limitErr.Bucket, limitErr.RetryAfter, test.wantBucket, test.wantRetry)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+// Package ratelimit provides client-side rate limiting for the OpenRouter and Groq clients.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrRateLimited is matched by every *LimitError, use errors.Is(err, ratelimit.ErrRateLimited).
+var ErrRateLimited = errors.New("rate limited")
+
+// Request describes the cost of a single HTTP request.
+type Request struct {
+	APIKey string
+	Model  string
+
+	// Estimated tokens for the request, corrected by Observe once the real usage is known
+	Tokens int
+
+	// Seconds of audio sent, 0 if unknown before the request (e.g. an audio URL)
+	AudioSeconds float64
+}
+
+// Observation describes the response to a Request.
+type Observation struct {
+	StatusCode int
+	Header     http.Header
+
+	// Tokens actually used, 0 if unknown
+	Tokens int
+
+	// Seconds of audio actually processed, 0 if unknown
+	AudioSeconds float64
+}
+
+// Limiter is consulted before and after every HTTP request a client makes,
+// including retries and fallbacks.
+type Limiter interface {
+	// Wait blocks until req is allowed or ctx is done.
+	// Limiters in fail fast mode return a *LimitError instead of blocking.
+	Wait(ctx context.Context, req Request) error
+
+	// Observe adapts the limiter to the response of req,
+	// e.g. its x-ratelimit-* headers and the actual usage.
+	Observe(req Request, obs Observation)
+}
+
+// LimitError is returned by a fail fast Limiter when a request isn't allowed yet.
+type LimitError struct {
+	// The bucket that is exhausted, e.g. "requests", "tokens" or "audio_seconds"
+	Bucket string
+	Key    string
+
+	// When the request would be allowed
+	RetryAfter time.Duration
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("rate limit for %s of %q exceeded, retry after %s", e.Bucket, e.Key, e.RetryAfter)
+}
+
+func (e *LimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
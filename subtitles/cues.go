@@ -0,0 +1,226 @@
+// Package subtitles renders transcriptions as SRT, WebVTT or JSON captions.
+//
+// Cue text comes from the segments, which unlike the words have punctuation. Cues are timed
+// with word timestamps when the transcription has them (see llmtypes.TimestampGranularityWord),
+// otherwise with segment timestamps, spreading each segment's time over its words by length.
+package subtitles
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	t "github.com/Floris22/go-llm/v2/llmtypes"
+)
+
+// Options controls how a transcription is split into cues. Zero values use the defaults.
+type Options struct {
+	// Maximum characters per line, defaults to 42
+	MaxLineLength int
+
+	// Maximum lines per cue, defaults to 2
+	MaxLines int
+
+	// Maximum time a cue is shown, defaults to 7 seconds
+	MaxCueDuration time.Duration
+}
+
+func (o *Options) withDefaults() Options {
+	var options Options
+	if o != nil {
+		options = *o
+	}
+	if options.MaxLineLength <= 0 {
+		options.MaxLineLength = 42
+	}
+	if options.MaxLines <= 0 {
+		options.MaxLines = 2
+	}
+	if options.MaxCueDuration <= 0 {
+		options.MaxCueDuration = 7 * time.Second
+	}
+	return options
+}
+
+// Cue is a caption shown from Start until End.
+type Cue struct {
+	// Position of the cue, starting at 1
+	Index int
+
+	Start time.Duration
+	End   time.Duration
+	Lines []string
+}
+
+// Text returns the lines of the cue joined by newlines.
+func (c Cue) Text() string {
+	return strings.Join(c.Lines, "\n")
+}
+
+type timedWord struct {
+	text       string
+	start      time.Duration
+	end        time.Duration
+	segmentEnd bool
+}
+
+// Cues splits transcription into cues. A cue ends at the end of a sentence or segment,
+// or before the word that would exceed the line or duration limits.
+func Cues(transcription t.GroqTranscriptionResponse, options *Options) []Cue {
+	o := options.withDefaults()
+
+	var cues []Cue
+	var current []timedWord
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		cues = append(cues, Cue{
+			Index: len(cues) + 1,
+			Start: current[0].start,
+			End:   max(current[len(current)-1].end, current[0].start),
+			Lines: wrap(current, o.MaxLineLength),
+		})
+		current = nil
+	}
+
+	for _, word := range timedWords(transcription) {
+		if len(current) > 0 {
+			tooLong := len(wrap(append(current, word), o.MaxLineLength)) > o.MaxLines
+			tooSlow := word.end-current[0].start > o.MaxCueDuration
+			if tooLong || tooSlow {
+				flush()
+			}
+		}
+		current = append(current, word)
+		if word.segmentEnd || endsSentence(word.text) {
+			flush()
+		}
+	}
+	flush()
+	return cues
+}
+
+// timedWords returns the words of transcription with their times.
+func timedWords(transcription t.GroqTranscriptionResponse) []timedWord {
+	segments := transcription.Segments
+	if len(transcription.Words) == 0 {
+		if len(segments) == 0 {
+			segments = []t.TranscriptionSegment{{End: transcription.Duration, Text: transcription.Text}}
+		}
+		var words []timedWord
+		for _, segment := range segments {
+			words = append(words, spreadSegment(segment)...)
+		}
+		return words
+	}
+
+	// Word timestamps have no punctuation, so they only time the text of the segment they fall in.
+	// The last group holds the words after the segments, or all words without segments.
+	groups := make([][]t.TranscriptionWord, len(segments)+1)
+	segment := 0
+	for _, word := range transcription.Words {
+		if strings.TrimSpace(word.Word) == "" {
+			continue
+		}
+		middle := (word.Start + word.End) / 2
+		for segment < len(segments) && middle >= segments[segment].End {
+			segment++
+		}
+		groups[segment] = append(groups[segment], word)
+	}
+
+	var words []timedWord
+	for i, segment := range segments {
+		if len(groups[i]) == 0 {
+			words = append(words, spreadSegment(segment)...)
+		} else {
+			words = append(words, segmentWords(segment, groups[i])...)
+		}
+	}
+	for _, word := range groups[len(segments)] {
+		words = append(words, timedWord{
+			text:  strings.TrimSpace(word.Word),
+			start: seconds(word.Start),
+			end:   seconds(word.End),
+		})
+	}
+	return words
+}
+
+// segmentWords returns the words of segment's text timed by the word timestamps within it.
+// When the counts differ, each word of the text gets the times of its share of the timestamps.
+func segmentWords(segment t.TranscriptionSegment, timestamps []t.TranscriptionWord) []timedWord {
+	fields := strings.Fields(segment.Text)
+	words := make([]timedWord, len(fields))
+	n, m := len(timestamps), len(fields)
+	for i, field := range fields {
+		first := i * n / m
+		last := max(first, (i+1)*n/m-1)
+		words[i] = timedWord{
+			text:       field,
+			start:      seconds(timestamps[first].Start),
+			end:        seconds(timestamps[last].End),
+			segmentEnd: i == m-1,
+		}
+	}
+	return words
+}
+
+// spreadSegment returns the words of segment's text, spreading its time over them by their length.
+func spreadSegment(segment t.TranscriptionSegment) []timedWord {
+	fields := strings.Fields(segment.Text)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	total := 0
+	for _, field := range fields {
+		total += utf8.RuneCountInString(field)
+	}
+	start, end := seconds(segment.Start), seconds(segment.End)
+	elapsed := 0
+	words := make([]timedWord, len(fields))
+	for i, field := range fields {
+		wordStart := start + (end-start)*time.Duration(elapsed)/time.Duration(total)
+		elapsed += utf8.RuneCountInString(field)
+		words[i] = timedWord{
+			text:       field,
+			start:      wordStart,
+			end:        start + (end-start)*time.Duration(elapsed)/time.Duration(total),
+			segmentEnd: i == len(fields)-1,
+		}
+	}
+	return words
+}
+
+// wrap splits words into lines of at most maxLength characters at word boundaries.
+// Words longer than maxLength get a line of their own.
+func wrap(words []timedWord, maxLength int) []string {
+	var lines []string
+	var line strings.Builder
+	for _, word := range words {
+		length := utf8.RuneCountInString(line.String())
+		if length > 0 && length+1+utf8.RuneCountInString(word.text) > maxLength {
+			lines = append(lines, line.String())
+			line.Reset()
+		}
+		if line.Len() > 0 {
+			line.WriteByte(' ')
+		}
+		line.WriteString(word.text)
+	}
+	if line.Len() > 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+func endsSentence(word string) bool {
+	return strings.HasSuffix(word, ".") || strings.HasSuffix(word, "?") || strings.HasSuffix(word, "!") ||
+		strings.HasSuffix(word, "…")
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
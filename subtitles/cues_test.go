@@ -0,0 +1,95 @@
+package subtitles
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Floris22/go-llm/v2/llmtypes"
+)
+
+func TestCuesBreakAtSegments(t *testing.T) {
+	words := func(texts ...string) []llmtypes.TranscriptionWord {
+		var words []llmtypes.TranscriptionWord
+		for i, text := range texts {
+			words = append(words, llmtypes.TranscriptionWord{Word: text, Start: float64(i) * 0.5, End: float64(i)*0.5 + 0.4})
+		}
+		return words
+	}
+
+	tests := []struct {
+		name          string
+		transcription llmtypes.GroqTranscriptionResponse
+		want          []string
+	}{
+		{
+			name: "words without punctuation",
+			transcription: llmtypes.GroqTranscriptionResponse{
+				Segments: []llmtypes.TranscriptionSegment{
+					{Start: 0, End: 1.5, Text: " Hello there friend."},
+					{Start: 1.5, End: 3, Text: " How are you?"},
+				},
+				Words: words("Hello", "there", "friend", "How", "are", "you"),
+			},
+			want: []string{"Hello there friend.", "How are you?"},
+		},
+		{
+			name: "punctuation within a segment",
+			transcription: llmtypes.GroqTranscriptionResponse{
+				Segments: []llmtypes.TranscriptionSegment{{Start: 0, End: 3, Text: " Well, hello. How are you?"}},
+				Words:    words("Well", "hello", "How", "are", "you"),
+			},
+			want: []string{"Well, hello.", "How are you?"},
+		},
+		{
+			name: "segments only",
+			transcription: llmtypes.GroqTranscriptionResponse{
+				Segments: []llmtypes.TranscriptionSegment{
+					{Start: 0, End: 1.5, Text: " Hello there friend"},
+					{Start: 1.5, End: 3, Text: " How are you"},
+				},
+			},
+			want: []string{"Hello there friend", "How are you"},
+		},
+		{
+			name: "words without segments",
+			transcription: llmtypes.GroqTranscriptionResponse{
+				Words: words("Hello", "there.", "How", "are", "you"),
+			},
+			want: []string{"Hello there.", "How are you"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cues := Cues(test.transcription, nil)
+			if len(cues) != len(test.want) {
+				t.Fatalf("got %d cues %+v, want %d", len(cues), cues, len(test.want))
+			}
+			for i, cue := range cues {
+				if cue.Text() != test.want[i] {
+					t.Errorf("cue %d = %q, want %q", i, cue.Text(), test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCuesTimedByWords(t *testing.T) {
+	transcription := llmtypes.GroqTranscriptionResponse{
+		Segments: []llmtypes.TranscriptionSegment{{Start: 0, End: 10, Text: " It's twenty-five, right?"}},
+		Words: []llmtypes.TranscriptionWord{
+			{Word: "It's", Start: 1, End: 1.5},
+			{Word: "twenty", Start: 2, End: 2.5},
+			{Word: "five", Start: 2.5, End: 3},
+			{Word: "right", Start: 4, End: 4.5},
+		},
+	}
+
+	cues := Cues(transcription, nil)
+	if len(cues) != 1 || cues[0].Text() != "It's twenty-five, right?" {
+		t.Fatalf("cues = %+v, want the segment text with its punctuation", cues)
+	}
+	if cues[0].Start != time.Second || cues[0].End != 4500*time.Millisecond {
+		t.Errorf("cue = %v to %v, want the times of the first and last word", cues[0].Start, cues[0].End)
+	}
+}
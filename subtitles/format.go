@@ -0,0 +1,74 @@
+package subtitles
+
+import (
+	"encoding/json/v2"
+	"fmt"
+	"strings"
+	"time"
+
+	t "github.com/Floris22/go-llm/v2/llmtypes"
+)
+
+// SRT renders transcription as SubRip subtitles.
+func SRT(transcription t.GroqTranscriptionResponse, options *Options) string {
+	var b strings.Builder
+	for _, cue := range Cues(transcription, options) {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", cue.Index, timestamp(cue.Start, ","), timestamp(cue.End, ","), cue.Text())
+	}
+	return b.String()
+}
+
+// WebVTT renders transcription as WebVTT subtitles.
+func WebVTT(transcription t.GroqTranscriptionResponse, options *Options) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for _, cue := range Cues(transcription, options) {
+		// Cue text must not contain "-->" and escapes & and <
+		text := strings.NewReplacer("&", "&amp;", "<", "&lt;", "-->", "--&gt;").Replace(cue.Text())
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", cue.Index, timestamp(cue.Start, "."), timestamp(cue.End, "."), text)
+	}
+	return b.String()
+}
+
+// JSONCaptions is the JSON caption format, times are in seconds.
+type JSONCaptions struct {
+	Language string        `json:"language,omitempty"`
+	Duration float64       `json:"duration"`
+	Captions []JSONCaption `json:"captions"`
+}
+
+type JSONCaption struct {
+	Index int      `json:"index"`
+	Start float64  `json:"start"`
+	End   float64  `json:"end"`
+	Text  string   `json:"text"`
+	Lines []string `json:"lines"`
+}
+
+// JSON renders transcription as JSONCaptions.
+func JSON(transcription t.GroqTranscriptionResponse, options *Options) ([]byte, error) {
+	captions := JSONCaptions{
+		Language: transcription.Language,
+		Duration: transcription.Duration,
+		Captions: []JSONCaption{},
+	}
+	for _, cue := range Cues(transcription, options) {
+		captions.Captions = append(captions.Captions, JSONCaption{
+			Index: cue.Index,
+			Start: cue.Start.Seconds(),
+			End:   cue.End.Seconds(),
+			Text:  strings.Join(cue.Lines, " "),
+			Lines: cue.Lines,
+		})
+	}
+	return json.Marshal(captions)
+}
+
+// timestamp formats d as HH:MM:SS followed by separator and milliseconds.
+func timestamp(d time.Duration, separator string) string {
+	d = d.Round(time.Millisecond)
+	return fmt.Sprintf(
+		"%02d:%02d:%02d%s%03d",
+		int(d/time.Hour), int(d/time.Minute)%60, int(d/time.Second)%60, separator, int(d/time.Millisecond)%1000,
+	)
+}